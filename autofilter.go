@@ -0,0 +1,142 @@
+package excl
+
+import (
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// filterDatabaseName オートフィルタの範囲を表す隠し名前
+const filterDatabaseName = "_xlnm._FilterDatabase"
+
+// FilterOperator カスタムフィルタの比較演算子
+type FilterOperator string
+
+const (
+	// FilterEqual 等しい
+	FilterEqual FilterOperator = "equal"
+	// FilterNotEqual 等しくない
+	FilterNotEqual FilterOperator = "notEqual"
+	// FilterGreaterThan より大きい
+	FilterGreaterThan FilterOperator = "greaterThan"
+	// FilterGreaterThanOrEqual 以上
+	FilterGreaterThanOrEqual FilterOperator = "greaterThanOrEqual"
+	// FilterLessThan より小さい
+	FilterLessThan FilterOperator = "lessThan"
+	// FilterLessThanOrEqual 以下
+	FilterLessThanOrEqual FilterOperator = "lessThanOrEqual"
+)
+
+// CustomFilter 比較演算子による条件
+// Valueには"*"と"?"のワイルドカードが使える
+type CustomFilter struct {
+	Operator FilterOperator
+	Value    string
+}
+
+// FilterColumn オートフィルタの列ごとの条件
+type FilterColumn struct {
+	// Col 条件を設定する列("B"など)
+	Col string
+	// Values 表示する値の一覧
+	Values []string
+	// Blank 空白のセルも表示する
+	Blank bool
+	// Custom 比較演算子による条件(2つまで)。Valuesとは同時に指定できない
+	Custom []CustomFilter
+	// And Customの条件をすべて満たす行を表示する(falseの場合はいずれかを満たす行)
+	And bool
+}
+
+// SetAutoFilter set the auto filter to the range such as "A1:H1000"
+// The first row of the range is the header row. The columns are filtered by the conditions,
+// but rows are not hidden until the filter is reapplied in Excel.
+func (sheet *Sheet) SetAutoFilter(ref string, columns ...FilterColumn) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	r, err := parseRange(ref)
+	if err != nil {
+		return err
+	}
+	tag := &Tag{Name: xml.Name{Local: "autoFilter"}}
+	tag.setAttr("ref", r.String())
+	for _, column := range columns {
+		filter, err := filterColumnTag(r, column)
+		if err != nil {
+			return err
+		}
+		tag.Children = append(tag.Children, filter)
+	}
+	sheet.worksheet.insertChild(tag, worksheetOrder)
+	if sheet.workbook != nil {
+		index := sheet.workbook.sheetIndex(sheet)
+		sheet.workbook.setDefinedName(filterDatabaseName, index, absoluteRef(sheet.xml.Name, r), true)
+	}
+	return nil
+}
+
+// RemoveAutoFilter remove the auto filter
+func (sheet *Sheet) RemoveAutoFilter() error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	sheet.worksheet.removeChild("autoFilter")
+	if sheet.workbook != nil {
+		sheet.workbook.deleteDefinedName(filterDatabaseName, sheet.workbook.sheetIndex(sheet))
+	}
+	return nil
+}
+
+// filterColumnTag 列の条件からfilterColumnタグを作成する
+func filterColumnTag(r cellRange, column FilterColumn) (*Tag, error) {
+	colNo := ColNumPosition(strings.ToUpper(column.Col))
+	if colNo < r.minCol || r.maxCol < colNo {
+		return nil, errors.New("The column [" + column.Col + "] is out of the filter range.")
+	}
+	hasValues := len(column.Values) > 0 || column.Blank
+	if hasValues && len(column.Custom) > 0 {
+		return nil, errors.New("The values and the custom filters cannot be set to the same column.")
+	}
+	if !hasValues && len(column.Custom) == 0 {
+		return nil, errors.New("The column [" + column.Col + "] has no filter condition.")
+	}
+	if len(column.Custom) > 2 {
+		return nil, errors.New("The number of custom filters should be 2 or less.")
+	}
+	tag := &Tag{Name: xml.Name{Local: "filterColumn"}}
+	tag.setAttr("colId", strconv.Itoa(colNo-r.minCol))
+	if hasValues {
+		filters := &Tag{Name: xml.Name{Local: "filters"}}
+		if column.Blank {
+			filters.setAttr("blank", "1")
+		}
+		for _, value := range column.Values {
+			filter := &Tag{Name: xml.Name{Local: "filter"}}
+			filter.setAttr("val", value)
+			filters.Children = append(filters.Children, filter)
+		}
+		tag.Children = append(tag.Children, filters)
+		return tag, nil
+	}
+	customs := &Tag{Name: xml.Name{Local: "customFilters"}}
+	if column.And {
+		customs.setAttr("and", "1")
+	}
+	for _, custom := range column.Custom {
+		switch custom.Operator {
+		case "", FilterEqual, FilterNotEqual, FilterGreaterThan, FilterGreaterThanOrEqual, FilterLessThan, FilterLessThanOrEqual:
+		default:
+			return nil, errors.New("The filter operator [" + string(custom.Operator) + "] is invalid.")
+		}
+		filter := &Tag{Name: xml.Name{Local: "customFilter"}}
+		if custom.Operator != "" && custom.Operator != FilterEqual {
+			filter.setAttr("operator", string(custom.Operator))
+		}
+		filter.setAttr("val", custom.Value)
+		customs.Children = append(customs.Children, filter)
+	}
+	tag.Children = append(tag.Children, customs)
+	return tag, nil
+}
@@ -0,0 +1,75 @@
+package excl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestSetAutoFilter(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	workbook.OpenSheet("Sheet1")
+	sheet, _ := workbook.OpenSheet("My Sheet")
+	sheet.GetRow(1).SetString("name", 1)
+	err := sheet.SetAutoFilter("A1:H1000",
+		FilterColumn{Col: "B", Values: []string{"apple", "orange"}, Blank: true},
+		FilterColumn{Col: "D", Custom: []CustomFilter{{FilterGreaterThan, "10"}, {FilterLessThanOrEqual, "100"}}, And: true},
+	)
+	if err != nil {
+		t.Fatal("auto filter should be set.", err.Error())
+	}
+	if err := sheet.SetAutoFilter("A1:H1000", FilterColumn{Col: "J", Values: []string{"x"}}); err == nil {
+		t.Error("column out of the range should be error.")
+	}
+	if err := sheet.SetAutoFilter("A1:H1000", FilterColumn{Col: "B", Values: []string{"x"}, Custom: []CustomFilter{{FilterEqual, "y"}}}); err == nil {
+		t.Error("values and custom filters should not be set at the same time.")
+	}
+	if err := sheet.SetAutoFilter("A1:H1000", FilterColumn{Col: "B", Custom: []CustomFilter{{"like", "y"}}}); err == nil {
+		t.Error("invalid operator should be error.")
+	}
+	sheet.Close()
+
+	f, _ := workbook.storage.Open("xl/worksheets/sheet2.xml")
+	b, _ := ioutil.ReadAll(f)
+	f.Close()
+	expected := `</sheetData><autoFilter ref="A1:H1000"><filterColumn colId="1"><filters blank="1"><filter val="apple"></filter><filter val="orange"></filter></filters></filterColumn>` +
+		`<filterColumn colId="3"><customFilters and="1"><customFilter operator="greaterThan" val="10"></customFilter><customFilter operator="lessThanOrEqual" val="100"></customFilter></customFilters></filterColumn></autoFilter>`
+	if !strings.Contains(string(b), expected) {
+		t.Error("autoFilter should be written after sheetData.", string(b))
+	}
+
+	var buf bytes.Buffer
+	xml.NewEncoder(&buf).Encode(workbook.workbookTag)
+	expected = `</sheets><definedNames><definedName name="_xlnm._FilterDatabase" localSheetId="1" hidden="1">&#39;My Sheet&#39;!$A$1:$H$1000</definedName></definedNames>`
+	if !strings.Contains(buf.String(), expected) {
+		t.Error("hidden defined name should be added.", buf.String())
+	}
+
+	sheet, _ = workbook.OpenSheet("My Sheet")
+	if err := sheet.RemoveAutoFilter(); err != nil {
+		t.Error("auto filter should be removed.", err.Error())
+	}
+	if sheet.worksheet.getChild("autoFilter") != nil {
+		t.Error("autoFilter tag should be removed.")
+	}
+	if workbook.workbookTag.getChild("definedNames") != nil {
+		t.Error("defined name should be removed.")
+	}
+}
+
+func TestAbsoluteRef(t *testing.T) {
+	r, _ := parseRange("A1:H10")
+	if ref := absoluteRef("Sheet1", r); ref != "Sheet1!$A$1:$H$10" {
+		t.Error("reference should be Sheet1!$A$1:$H$10 but", ref)
+	}
+	r, _ = parseRange("B2")
+	if ref := absoluteRef("O'Brien's", r); ref != "'O''Brien''s'!$B$2" {
+		t.Error("reference should be quoted but", ref)
+	}
+	if ref := absoluteRef("AB12", r); ref != "'AB12'!$B$2" {
+		t.Error("sheet name like a cell should be quoted but", ref)
+	}
+}
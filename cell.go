@@ -2,9 +2,12 @@ package excl
 
 import (
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"math"
 	"regexp"
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -18,8 +21,34 @@ type Cell struct {
 	styles        *Styles
 	style         *Style
 	changed       bool
+	row           *Row
 }
 
+// CellType セルに格納されている値の種類
+type CellType int
+
+const (
+	// CellTypeBlank 値が存在しない
+	CellTypeBlank CellType = iota
+	// CellTypeNumber 数値(t="n")
+	CellTypeNumber
+	// CellTypeSharedString 共有文字列(t="s")
+	CellTypeSharedString
+	// CellTypeString 数式の結果の文字列(t="str")
+	CellTypeString
+	// CellTypeInlineString インライン文字列(t="inlineStr")
+	CellTypeInlineString
+	// CellTypeBool 真偽値(t="b")
+	CellTypeBool
+	// CellTypeError エラー値(t="e")
+	CellTypeError
+	// CellTypeDate ISO8601形式の日付(t="d")
+	CellTypeDate
+)
+
+// excelEpoch 1900年日付システムの基準日
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
 // NewCell は新しくcellを作成する
 func NewCell(tag *Tag, sharedStrings *SharedStrings, styles *Styles) *Cell {
 	cell := &Cell{cell: tag, sharedStrings: sharedStrings, colNo: -1, styles: styles}
@@ -54,7 +83,11 @@ func (cell *Cell) setValue(val string) *Cell {
 }
 
 // SetString 文字列を追加する
+// 結合範囲の左上以外のセルには値を設定しない
 func (cell *Cell) SetString(val string) *Cell {
+	if cell.mergedAway() {
+		return cell
+	}
 	v := cell.sharedStrings.AddString(val)
 	cell.setValue(strconv.Itoa(v))
 	cell.cell.setAttr("t", "s")
@@ -62,7 +95,11 @@ func (cell *Cell) SetString(val string) *Cell {
 }
 
 // SetNumber set a number in a cell
+// The value is ignored if the cell is merged and is not the top-left cell.
 func (cell *Cell) SetNumber(val interface{}) *Cell {
+	if cell.mergedAway() {
+		return cell
+	}
 	var str string
 	switch t := val.(type) {
 	case int:
@@ -87,8 +124,27 @@ func (cell *Cell) SetNumber(val interface{}) *Cell {
 	return cell
 }
 
+// SetBool set a boolean in a cell
+// The value is ignored if the cell is merged and is not the top-left cell.
+func (cell *Cell) SetBool(val bool) *Cell {
+	if cell.mergedAway() {
+		return cell
+	}
+	if val {
+		cell.setValue("1")
+	} else {
+		cell.setValue("0")
+	}
+	cell.cell.setAttr("t", "b")
+	return cell
+}
+
 // SetFormula set a formula in a cell
+// The formula is ignored if the cell is merged and is not the top-left cell.
 func (cell *Cell) SetFormula(val string) *Cell {
+	if cell.mergedAway() {
+		return cell
+	}
 	tag := &Tag{
 		Name: xml.Name{Local: "f"},
 		Children: []interface{}{
@@ -101,7 +157,11 @@ func (cell *Cell) SetFormula(val string) *Cell {
 }
 
 // SetDate set a date in a cell
+// The value is ignored if the cell is merged and is not the top-left cell.
 func (cell *Cell) SetDate(val time.Time) *Cell {
+	if cell.mergedAway() {
+		return cell
+	}
 	cell.cell.setAttr("t", "d")
 	cell.setValue(val.Format("2006-01-02T15:04:05.999999999"))
 	if cell.GetStyle().NumFmtID == 0 {
@@ -110,6 +170,180 @@ func (cell *Cell) SetDate(val time.Time) *Cell {
 	return cell
 }
 
+// GetColNo get the column number(from 1)
+func (cell *Cell) GetColNo() int {
+	return cell.colNo
+}
+
+// Type get the type of the value in a cell
+func (cell *Cell) Type() CellType {
+	t, _ := cell.cell.getAttr("t")
+	switch t {
+	case "inlineStr":
+		if cell.cell.getChild("is") == nil {
+			return CellTypeBlank
+		}
+		return CellTypeInlineString
+	}
+	if cell.cell.getChild("v") == nil {
+		return CellTypeBlank
+	}
+	switch t {
+	case "s":
+		return CellTypeSharedString
+	case "str":
+		return CellTypeString
+	case "b":
+		return CellTypeBool
+	case "e":
+		return CellTypeError
+	case "d":
+		return CellTypeDate
+	}
+	return CellTypeNumber
+}
+
+// rawValue get the text in <v> tag
+func (cell *Cell) rawValue() string {
+	if v := cell.cell.getChild("v"); v != nil {
+		return v.text()
+	}
+	return ""
+}
+
+// GetString get the value of a cell as string
+func (cell *Cell) GetString() (string, error) {
+	switch cell.Type() {
+	case CellTypeBlank:
+		return "", nil
+	case CellTypeSharedString:
+		index, err := strconv.Atoi(strings.TrimSpace(cell.rawValue()))
+		if err != nil {
+			return "", err
+		}
+		return cell.sharedStrings.GetString(index)
+	case CellTypeInlineString:
+		return plainText(cell.cell.getChild("is")), nil
+	case CellTypeBool:
+		if cell.rawValue() == "1" {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	}
+	return cell.rawValue(), nil
+}
+
+// GetNumber get the value of a cell as number
+func (cell *Cell) GetNumber() (float64, error) {
+	switch cell.Type() {
+	case CellTypeBlank:
+		return 0, nil
+	case CellTypeBool:
+		if cell.rawValue() == "1" {
+			return 1, nil
+		}
+		return 0, nil
+	case CellTypeDate:
+		t, err := parseISODate(cell.rawValue())
+		if err != nil {
+			return 0, err
+		}
+		return timeToExcelTime(t), nil
+	case CellTypeError:
+		return 0, errors.New("The cell has an error value " + cell.rawValue() + ".")
+	}
+	str, err := cell.GetString()
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(strings.TrimSpace(str), 64)
+}
+
+// GetBool get the value of a cell as bool
+func (cell *Cell) GetBool() (bool, error) {
+	switch cell.Type() {
+	case CellTypeBlank:
+		return false, nil
+	case CellTypeBool:
+		return cell.rawValue() == "1", nil
+	case CellTypeNumber:
+		f, err := cell.GetNumber()
+		return f != 0, err
+	case CellTypeError:
+		return false, errors.New("The cell has an error value " + cell.rawValue() + ".")
+	}
+	str, err := cell.GetString()
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToUpper(strings.TrimSpace(str)) {
+	case "TRUE":
+		return true, nil
+	case "FALSE":
+		return false, nil
+	}
+	return false, errors.New("The cell value [" + str + "] is not bool.")
+}
+
+// GetTime get the value of a cell as time
+// Numbers are treated as serial dates of the 1900 date system
+func (cell *Cell) GetTime() (time.Time, error) {
+	switch cell.Type() {
+	case CellTypeBlank:
+		return time.Time{}, nil
+	case CellTypeNumber:
+		f, err := cell.GetNumber()
+		if err != nil {
+			return time.Time{}, err
+		}
+		return excelTimeToTime(f), nil
+	case CellTypeError:
+		return time.Time{}, errors.New("The cell has an error value " + cell.rawValue() + ".")
+	}
+	str, err := cell.GetString()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return parseISODate(strings.TrimSpace(str))
+}
+
+// GetFormula get the formula of a cell
+func (cell *Cell) GetFormula() string {
+	if f := cell.cell.getChild("f"); f != nil {
+		return f.text()
+	}
+	return ""
+}
+
+// Value get the value of a cell as string, float64, bool, time.Time or nil
+// Numbers with date format are returned as time.Time
+func (cell *Cell) Value() interface{} {
+	switch cell.Type() {
+	case CellTypeBlank:
+		return nil
+	case CellTypeNumber:
+		f, err := cell.GetNumber()
+		if err != nil {
+			return cell.rawValue()
+		}
+		if cell.styles != nil && cell.styles.isDateFormat(cell.GetStyle().NumFmtID) {
+			return excelTimeToTime(f)
+		}
+		return f
+	case CellTypeBool:
+		b, _ := cell.GetBool()
+		return b
+	case CellTypeDate:
+		t, err := cell.GetTime()
+		if err != nil {
+			return cell.rawValue()
+		}
+		return t
+	}
+	str, _ := cell.GetString()
+	return str
+}
+
 // GetStyle Style構造体を取得する
 func (cell *Cell) GetStyle() *Style {
 	if cell.style == nil {
@@ -127,6 +361,14 @@ func (cell *Cell) GetStyle() *Style {
 			Vertical:   style.Vertical,
 			Wrap:       style.Wrap,
 		}
+		if style.Locked != nil {
+			locked := *style.Locked
+			cell.style.Locked = &locked
+		}
+		if style.FormulaHidden != nil {
+			hidden := *style.FormulaHidden
+			cell.style.FormulaHidden = &hidden
+		}
 	}
 	return cell.style
 }
@@ -200,6 +442,14 @@ func (cell *Cell) SetStyle(style *Style) *Cell {
 	if style.Wrap != 0 {
 		cell.style.Wrap = style.Wrap
 	}
+	if style.Locked != nil {
+		locked := *style.Locked
+		cell.style.Locked = &locked
+	}
+	if style.FormulaHidden != nil {
+		hidden := *style.FormulaHidden
+		cell.style.FormulaHidden = &hidden
+	}
 	cell.changed = true
 	return cell
 }
@@ -211,6 +461,39 @@ func (cell *Cell) resetStyleIndex() {
 	}
 }
 
+// parseISODate parse the ISO 8601 date string used in t="d" cells
+func parseISODate(str string) (time.Time, error) {
+	layouts := []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", "2006-01-02T15:04", "2006-01-02", "15:04:05.999999999"}
+	for _, layout := range layouts {
+		if t, err := time.Parse(layout, str); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, errors.New("The value [" + str + "] is not a date.")
+}
+
+// excelTimeToTime convert serial date to time
+func excelTimeToTime(serial float64) time.Time {
+	epoch := excelEpoch
+	if serial < 60 {
+		// 1900/2/29 does not exist but excel has it
+		epoch = epoch.AddDate(0, 0, 1)
+	}
+	days := math.Floor(serial)
+	msec := math.Round((serial - days) * 86400000)
+	return epoch.AddDate(0, 0, int(days)).Add(time.Duration(msec) * time.Millisecond)
+}
+
+// timeToExcelTime convert time to serial date
+func timeToExcelTime(t time.Time) float64 {
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	serial := float64(t.Unix()-excelEpoch.Unix())/86400 + float64(t.Nanosecond())/86400e9
+	if serial < 61 {
+		serial--
+	}
+	return serial
+}
+
 // MarshalXML create xml for cell
 func (cell *Cell) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	start.Name = cell.cell.Name
@@ -3,7 +3,9 @@ package excl
 import (
 	"bytes"
 	"encoding/xml"
+	"math"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -299,3 +301,205 @@ func TestResetStyleIndex(t *testing.T) {
 		t.Error("style index should be 0 but", val)
 	}
 }
+
+func TestCellType(t *testing.T) {
+	cell := &Cell{cell: &Tag{}}
+	if cell.Type() != CellTypeBlank {
+		t.Error("cell type should be blank but", cell.Type())
+	}
+	cell.SetNumber(1)
+	if cell.Type() != CellTypeNumber {
+		t.Error("cell type should be number but", cell.Type())
+	}
+	types := map[string]CellType{
+		"s":   CellTypeSharedString,
+		"str": CellTypeString,
+		"b":   CellTypeBool,
+		"e":   CellTypeError,
+		"d":   CellTypeDate,
+		"n":   CellTypeNumber,
+	}
+	for attr, cellType := range types {
+		cell.cell.setAttr("t", attr)
+		if cell.Type() != cellType {
+			t.Error("cell type should be", cellType, "but", cell.Type())
+		}
+	}
+	cell.cell.setAttr("t", "inlineStr")
+	if cell.Type() != CellTypeBlank {
+		t.Error("cell type should be blank because is tag does not exist but", cell.Type())
+	}
+	cell.cell.Children = []interface{}{&Tag{Name: xml.Name{Local: "is"}}}
+	if cell.Type() != CellTypeInlineString {
+		t.Error("cell type should be inline string but", cell.Type())
+	}
+}
+
+func TestCellGetValue(t *testing.T) {
+	os.MkdirAll("temp/xl", 0755)
+	defer os.RemoveAll("temp/xl")
+	ss, _ := OpenSharedStrings("temp")
+	defer ss.Close()
+	ss.AddString("hello")
+	ss.AddString("world")
+	cell := &Cell{cell: &Tag{}, sharedStrings: ss}
+	cell.setValue("1")
+	cell.cell.setAttr("t", "s")
+	if str, err := cell.GetString(); err != nil || str != "world" {
+		t.Error("value should be world but", str, err)
+	}
+	cell.setValue("2")
+	if _, err := cell.GetString(); err == nil {
+		t.Error("shared string should not be found.")
+	}
+
+	cell.cell = &Tag{}
+	cell.cell.setAttr("t", "inlineStr")
+	cell.cell.Children = []interface{}{&Tag{Name: xml.Name{Local: "is"}, Children: []interface{}{
+		&Tag{Name: xml.Name{Local: "t"}, Children: []interface{}{xml.CharData("inline")}},
+	}}}
+	if str, _ := cell.GetString(); str != "inline" {
+		t.Error("value should be inline but", str)
+	}
+
+	cell.cell = &Tag{}
+	cell.cell.setAttr("t", "b")
+	cell.setValue("1")
+	if b, err := cell.GetBool(); err != nil || !b {
+		t.Error("value should be true but", b, err)
+	}
+	if str, _ := cell.GetString(); str != "TRUE" {
+		t.Error("value should be TRUE but", str)
+	}
+	if v, ok := cell.Value().(bool); !ok || !v {
+		t.Error("value should be true but", cell.Value())
+	}
+
+	cell.cell.setAttr("t", "str")
+	cell.setValue("false")
+	if b, err := cell.GetBool(); err != nil || b {
+		t.Error("value should be false but", b, err)
+	}
+
+	cell.cell.setAttr("t", "e")
+	cell.setValue("#DIV/0!")
+	if _, err := cell.GetNumber(); err == nil {
+		t.Error("error value should not be converted to number.")
+	}
+	if v, _ := cell.Value().(string); v != "#DIV/0!" {
+		t.Error("value should be #DIV/0! but", cell.Value())
+	}
+
+	cell.styles = &Styles{}
+	cell.SetNumber("12.5")
+	if f, err := cell.GetNumber(); err != nil || f != 12.5 {
+		t.Error("value should be 12.5 but", f, err)
+	}
+	if v, _ := cell.Value().(float64); v != 12.5 {
+		t.Error("value should be 12.5 but", cell.Value())
+	}
+	cell.style = &Style{NumFmtID: 14}
+	if v, _ := cell.Value().(time.Time); !v.Equal(time.Date(1900, 1, 12, 12, 0, 0, 0, time.UTC)) {
+		t.Error("value should be 1900-01-12 12:00 but", cell.Value())
+	}
+
+	date := time.Date(2017, 12, 7, 10, 20, 30, 0, time.UTC)
+	cell.SetDate(date)
+	if v, err := cell.GetTime(); err != nil || !v.Equal(date) {
+		t.Error("value should be", date, "but", v, err)
+	}
+	if f, _ := cell.GetNumber(); math.Abs(f-43076.4309) > 0.0001 {
+		t.Error("value should be 43076.4309 but", f)
+	}
+
+	cell.SetFormula("SUM(A1:A2)")
+	if f := cell.GetFormula(); f != "SUM(A1:A2)" {
+		t.Error("formula should be SUM(A1:A2) but", f)
+	}
+	if cell.Value() != nil {
+		t.Error("value should be nil but", cell.Value())
+	}
+}
+
+func TestExcelTime(t *testing.T) {
+	dates := map[float64]time.Time{
+		1:          time.Date(1900, 1, 1, 0, 0, 0, 0, time.UTC),
+		59:         time.Date(1900, 2, 28, 0, 0, 0, 0, time.UTC),
+		61:         time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC),
+		43076.5:    time.Date(2017, 12, 7, 12, 0, 0, 0, time.UTC),
+		2958465.75: time.Date(9999, 12, 31, 18, 0, 0, 0, time.UTC),
+	}
+	for serial, date := range dates {
+		if v := excelTimeToTime(serial); !v.Equal(date) {
+			t.Error("date should be", date, "but", v)
+		}
+		if v := timeToExcelTime(date); v != serial {
+			t.Error("serial should be", serial, "but", v)
+		}
+	}
+}
+
+func TestCellReadValues(t *testing.T) {
+	os.MkdirAll("temp/out", 0755)
+	defer os.RemoveAll("temp/out")
+	date := time.Date(2017, 12, 7, 0, 0, 0, 0, time.UTC)
+	w, _ := Create()
+	s, _ := w.OpenSheet("Sheet1")
+	r := s.GetRow(1)
+	r.SetString("hello", 1)
+	r.SetNumber(10.5, 2)
+	r.SetDate(date, 3)
+	r.SetFormula("B1*2", 4)
+	s.Close()
+	w.Save("temp/out/read.xlsx")
+
+	w, err := Open("temp/out/read.xlsx")
+	if err != nil {
+		t.Fatal("workbook should be opened.", err.Error())
+	}
+	defer w.Close()
+	s, _ = w.OpenSheet("Sheet1")
+	r = s.GetRow(1)
+	if v, _ := r.GetCell(1).GetString(); v != "hello" {
+		t.Error("value should be hello but", v)
+	}
+	if v, _ := r.GetCell(2).GetNumber(); v != 10.5 {
+		t.Error("value should be 10.5 but", v)
+	}
+	if v, _ := r.GetCell(3).Value().(time.Time); !v.Equal(date) {
+		t.Error("value should be", date, "but", v)
+	}
+	if v := r.GetCell(4).GetFormula(); v != "B1*2" {
+		t.Error("formula should be B1*2 but", v)
+	}
+}
+
+func TestCellSetStyleProtection(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	unlocked := false
+	cell := sheet.GetRow(2).GetCell(2).SetString("input")
+	cell.SetStyle(&Style{Locked: &unlocked})
+	unlocked = true
+	if cell.GetStyle().Locked == nil || *cell.GetStyle().Locked {
+		t.Error("cell should be unlocked.")
+	}
+	sheet.GetRow(3).GetCell(2).SetStyle(&Style{Horizontal: "left"})
+	sheet.Protect("", SheetProtection{})
+	sheet.Close()
+
+	b := new(bytes.Buffer)
+	xml.NewEncoder(b).Encode(workbook.Styles.cellXfs)
+	if strings.Count(b.String(), `<protection locked="0"></protection>`) != 1 {
+		t.Error("protection should be written once.", b.String())
+	}
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	if style := sheet.GetRow(2).GetCell(2).GetStyle(); style.Locked == nil || *style.Locked {
+		t.Error("unlocked style should be read.")
+	}
+	if style := sheet.GetRow(3).GetCell(2).GetStyle(); style.Locked != nil {
+		t.Error("default style should not have protection.")
+	}
+	sheet.Close()
+}
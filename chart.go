@@ -0,0 +1,276 @@
+package excl
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	chartContentType = "application/vnd.openxmlformats-officedocument.drawingml.chart+xml"
+	relTypeChart     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart"
+)
+
+// ChartType グラフの種類
+type ChartType string
+
+const (
+	// ChartBar 棒グラフ
+	ChartBar ChartType = "bar"
+	// ChartLine 折れ線グラフ
+	ChartLine ChartType = "line"
+	// ChartPie 円グラフ
+	ChartPie ChartType = "pie"
+	// ChartScatter 散布図
+	ChartScatter ChartType = "scatter"
+)
+
+// ChartSeries グラフの系列
+type ChartSeries struct {
+	// Name 系列名。"Sheet1!$B$1"のような参照または文字列
+	Name string
+	// Categories 項目の範囲("Sheet1!$A$2:$A$20"など)。散布図の場合はXの値の範囲
+	Categories string
+	// Values 値の範囲("Sheet1!$B$2:$B$20"など)
+	Values string
+}
+
+// ChartSpec グラフの設定
+type ChartSpec struct {
+	// Type グラフの種類
+	Type ChartType
+	// Title グラフのタイトル
+	Title string
+	// Series 系列
+	Series []ChartSeries
+	// XAxisTitle 横軸のタイトル
+	XAxisTitle string
+	// YAxisTitle 縦軸のタイトル
+	YAxisTitle string
+	// Legend 凡例の位置("r"、"l"、"t"、"b"、"tr")。空の場合は"r"、"none"の場合は表示しない
+	Legend string
+	// Horizontal 棒グラフを横向きにする
+	Horizontal bool
+}
+
+// AddChart add the chart to the range such as "E2:L20"
+func (sheet *Sheet) AddChart(ref string, spec ChartSpec) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	r, err := parseRange(ref)
+	if err != nil {
+		return err
+	}
+	chart, err := chartXML(spec)
+	if err != nil {
+		return err
+	}
+	d, err := sheet.getDrawing()
+	if err != nil {
+		return err
+	}
+
+	n := 1
+	for ; sheet.partExists(fmt.Sprintf("xl/charts/chart%d.xml", n)); n++ {
+	}
+	chartPath := fmt.Sprintf("xl/charts/chart%d.xml", n)
+	f, err := sheet.storage.Create(chartPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	f.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n")
+	if _, err = f.WriteString(chart); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if sheet.workbook != nil {
+		sheet.workbook.types.addOverride("/"+chartPath, chartContentType)
+	}
+	rid := d.rels.addRel(relTypeChart, relativePath(path.Dir(d.path), chartPath), false)
+	id := d.nextShapeID()
+	a := drawingAnchor{
+		fromRow: r.minRow, fromCol: r.minCol,
+		toRow: r.maxRow + 1, toCol: r.maxCol + 1,
+		twoCell: true,
+	}
+	d.addAnchor(a, graphicFrameTag(id, "Chart "+strconv.Itoa(id-1), rid))
+	return nil
+}
+
+// graphicFrameTag グラフを配置するxdr:graphicFrameタグを作成する
+func graphicFrameTag(id int, name string, rid string) *Tag {
+	cNvPr := &Tag{Name: xml.Name{Local: "xdr:cNvPr"}}
+	cNvPr.setAttr("id", strconv.Itoa(id))
+	cNvPr.setAttr("name", name)
+	nvPr := &Tag{
+		Name:     xml.Name{Local: "xdr:nvGraphicFramePr"},
+		Children: []interface{}{cNvPr, &Tag{Name: xml.Name{Local: "xdr:cNvGraphicFramePr"}}},
+	}
+	off := &Tag{Name: xml.Name{Local: "a:off"}}
+	off.setAttr("x", "0")
+	off.setAttr("y", "0")
+	ext := &Tag{Name: xml.Name{Local: "a:ext"}}
+	ext.setAttr("cx", "0")
+	ext.setAttr("cy", "0")
+	xfrm := &Tag{Name: xml.Name{Local: "xdr:xfrm"}, Children: []interface{}{off, ext}}
+	chart := &Tag{Name: xml.Name{Local: "c:chart"}}
+	chart.setAttr("xmlns:c", "http://schemas.openxmlformats.org/drawingml/2006/chart")
+	chart.setAttr("xmlns:r", "http://schemas.openxmlformats.org/officeDocument/2006/relationships")
+	chart.setAttr("r:id", rid)
+	data := &Tag{Name: xml.Name{Local: "a:graphicData"}, Children: []interface{}{chart}}
+	data.setAttr("uri", "http://schemas.openxmlformats.org/drawingml/2006/chart")
+	graphic := &Tag{Name: xml.Name{Local: "a:graphic"}, Children: []interface{}{data}}
+	frame := &Tag{Name: xml.Name{Local: "xdr:graphicFrame"}, Children: []interface{}{nvPr, xfrm, graphic}}
+	frame.setAttr("macro", "")
+	return frame
+}
+
+// chartXML グラフの設定からchartN.xmlの内容を作成する
+func chartXML(spec ChartSpec) (string, error) {
+	if len(spec.Series) == 0 {
+		return "", errors.New("The chart has no series.")
+	}
+	for _, s := range spec.Series {
+		if s.Values == "" {
+			return "", errors.New("The values of the series are empty.")
+		}
+		if spec.Type == ChartScatter && s.Categories == "" {
+			return "", errors.New("The x values of the scatter series are empty.")
+		}
+	}
+	switch spec.Legend {
+	case "", "none", "r", "l", "t", "b", "tr":
+	default:
+		return "", errors.New("The legend position [" + spec.Legend + "] is invalid.")
+	}
+
+	var b strings.Builder
+	b.WriteString(`<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">`)
+	b.WriteString(`<c:roundedCorners val="0"/><c:chart>`)
+	if spec.Title != "" {
+		writeChartTitle(&b, spec.Title)
+		b.WriteString(`<c:autoTitleDeleted val="0"/>`)
+	} else {
+		b.WriteString(`<c:autoTitleDeleted val="1"/>`)
+	}
+	b.WriteString(`<c:plotArea><c:layout/>`)
+	switch spec.Type {
+	case ChartBar:
+		barDir := "col"
+		if spec.Horizontal {
+			barDir = "bar"
+		}
+		fmt.Fprintf(&b, `<c:barChart><c:barDir val="%s"/><c:grouping val="clustered"/><c:varyColors val="0"/>`, barDir)
+		writeChartSeries(&b, spec)
+		b.WriteString(`<c:gapWidth val="150"/><c:axId val="1"/><c:axId val="2"/></c:barChart>`)
+	case ChartLine:
+		b.WriteString(`<c:lineChart><c:grouping val="standard"/><c:varyColors val="0"/>`)
+		writeChartSeries(&b, spec)
+		b.WriteString(`<c:marker val="1"/><c:axId val="1"/><c:axId val="2"/></c:lineChart>`)
+	case ChartPie:
+		b.WriteString(`<c:pieChart><c:varyColors val="1"/>`)
+		writeChartSeries(&b, spec)
+		b.WriteString(`<c:firstSliceAng val="0"/></c:pieChart>`)
+	case ChartScatter:
+		b.WriteString(`<c:scatterChart><c:scatterStyle val="lineMarker"/><c:varyColors val="0"/>`)
+		writeChartSeries(&b, spec)
+		b.WriteString(`<c:axId val="1"/><c:axId val="2"/></c:scatterChart>`)
+	default:
+		return "", errors.New("The chart type [" + string(spec.Type) + "] is invalid.")
+	}
+	if spec.Type != ChartPie {
+		xPos, yPos := "b", "l"
+		if spec.Horizontal && spec.Type == ChartBar {
+			xPos, yPos = "l", "b"
+		}
+		xAxis := "c:catAx"
+		if spec.Type == ChartScatter {
+			xAxis = "c:valAx"
+		}
+		writeChartAxis(&b, xAxis, 1, 2, xPos, spec.XAxisTitle, false)
+		writeChartAxis(&b, "c:valAx", 2, 1, yPos, spec.YAxisTitle, true)
+	}
+	b.WriteString(`</c:plotArea>`)
+	if spec.Legend != "none" {
+		pos := spec.Legend
+		if pos == "" {
+			pos = "r"
+		}
+		fmt.Fprintf(&b, `<c:legend><c:legendPos val="%s"/><c:overlay val="0"/></c:legend>`, pos)
+	}
+	b.WriteString(`<c:plotVisOnly val="1"/><c:dispBlanksAs val="gap"/></c:chart></c:chartSpace>`)
+	return b.String(), nil
+}
+
+// writeChartSeries 系列のタグを書き出す
+func writeChartSeries(b *strings.Builder, spec ChartSpec) {
+	for i, s := range spec.Series {
+		fmt.Fprintf(b, `<c:ser><c:idx val="%d"/><c:order val="%d"/>`, i, i)
+		if s.Name != "" {
+			if strings.Contains(s.Name, "!") {
+				b.WriteString(`<c:tx><c:strRef><c:f>` + escapeXML(s.Name) + `</c:f></c:strRef></c:tx>`)
+			} else {
+				b.WriteString(`<c:tx><c:v>` + escapeXML(s.Name) + `</c:v></c:tx>`)
+			}
+		}
+		switch spec.Type {
+		case ChartBar:
+			b.WriteString(`<c:invertIfNegative val="0"/>`)
+		case ChartLine:
+			b.WriteString(`<c:marker><c:symbol val="none"/></c:marker>`)
+		case ChartScatter:
+			b.WriteString(`<c:spPr><a:ln w="19050"><a:noFill/></a:ln></c:spPr>`)
+		}
+		if spec.Type == ChartScatter {
+			b.WriteString(`<c:xVal><c:numRef><c:f>` + escapeXML(s.Categories) + `</c:f></c:numRef></c:xVal>`)
+			b.WriteString(`<c:yVal><c:numRef><c:f>` + escapeXML(s.Values) + `</c:f></c:numRef></c:yVal>`)
+		} else {
+			if s.Categories != "" {
+				b.WriteString(`<c:cat><c:strRef><c:f>` + escapeXML(s.Categories) + `</c:f></c:strRef></c:cat>`)
+			}
+			b.WriteString(`<c:val><c:numRef><c:f>` + escapeXML(s.Values) + `</c:f></c:numRef></c:val>`)
+		}
+		if spec.Type == ChartLine || spec.Type == ChartScatter {
+			b.WriteString(`<c:smooth val="0"/>`)
+		}
+		b.WriteString(`</c:ser>`)
+	}
+}
+
+// writeChartAxis 軸のタグを書き出す
+func writeChartAxis(b *strings.Builder, name string, id int, crossID int, pos string, title string, gridlines bool) {
+	fmt.Fprintf(b, `<%s><c:axId val="%d"/><c:scaling><c:orientation val="minMax"/></c:scaling><c:delete val="0"/><c:axPos val="%s"/>`, name, id, pos)
+	if gridlines {
+		b.WriteString(`<c:majorGridlines/>`)
+	}
+	if title != "" {
+		writeChartTitle(b, title)
+	}
+	b.WriteString(`<c:majorTickMark val="out"/><c:minorTickMark val="none"/><c:tickLblPos val="nextTo"/>`)
+	fmt.Fprintf(b, `<c:crossAx val="%d"/><c:crosses val="autoZero"/>`, crossID)
+	if name == "c:catAx" {
+		b.WriteString(`<c:auto val="1"/><c:lblAlgn val="ctr"/><c:lblOffset val="100"/><c:noMultiLvlLbl val="0"/>`)
+	} else {
+		b.WriteString(`<c:crossBetween val="between"/>`)
+	}
+	fmt.Fprintf(b, `</%s>`, name)
+}
+
+// writeChartTitle タイトルのタグを書き出す
+func writeChartTitle(b *strings.Builder, title string) {
+	b.WriteString(`<c:title><c:tx><c:rich><a:bodyPr/><a:p><a:r><a:t>` + escapeXML(title) + `</a:t></a:r></a:p></c:rich></c:tx><c:overlay val="0"/></c:title>`)
+}
+
+// escapeXML XMLの特殊文字をエスケープする
+func escapeXML(str string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(str))
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package excl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddChart(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	err := sheet.AddChart("E2:L20", ChartSpec{
+		Type:       ChartBar,
+		Title:      "Sales & Profit",
+		XAxisTitle: "Month",
+		YAxisTitle: "Amount",
+		Series: []ChartSeries{
+			{Name: "Sheet1!$B$1", Categories: "Sheet1!$A$2:$A$13", Values: "Sheet1!$B$2:$B$13"},
+			{Name: "Profit", Categories: "Sheet1!$A$2:$A$13", Values: "Sheet1!$C$2:$C$13"},
+		},
+	})
+	if err != nil {
+		t.Fatal("chart should be added.", err.Error())
+	}
+	if err = sheet.AddChart("E22:L40", ChartSpec{Type: ChartPie, Legend: "b", Series: []ChartSeries{{Values: "Sheet1!$B$2:$B$13"}}}); err != nil {
+		t.Fatal("chart should be added.", err.Error())
+	}
+	if err = sheet.AddChart("A1:B2", ChartSpec{Type: ChartScatter, Series: []ChartSeries{{Values: "Sheet1!$B$2:$B$13"}}}); err == nil {
+		t.Error("scatter without x values should be error.")
+	}
+	if err = sheet.AddChart("A1:B2", ChartSpec{Type: "area", Series: []ChartSeries{{Values: "Sheet1!$B$2:$B$13"}}}); err == nil {
+		t.Error("invalid chart type should be error.")
+	}
+	if err = sheet.AddChart("A1:B2", ChartSpec{Type: ChartLine}); err == nil {
+		t.Error("chart without series should be error.")
+	}
+	sheet.Close()
+
+	str := readPart(workbook, "xl/charts/chart1.xml")
+	expected := []string{
+		`<c:title><c:tx><c:rich><a:bodyPr/><a:p><a:r><a:t>Sales &amp; Profit</a:t></a:r></a:p></c:rich></c:tx><c:overlay val="0"/></c:title>`,
+		`<c:barChart><c:barDir val="col"/><c:grouping val="clustered"/><c:varyColors val="0"/>`,
+		`<c:ser><c:idx val="0"/><c:order val="0"/><c:tx><c:strRef><c:f>Sheet1!$B$1</c:f></c:strRef></c:tx><c:invertIfNegative val="0"/>` +
+			`<c:cat><c:strRef><c:f>Sheet1!$A$2:$A$13</c:f></c:strRef></c:cat><c:val><c:numRef><c:f>Sheet1!$B$2:$B$13</c:f></c:numRef></c:val></c:ser>`,
+		`<c:tx><c:v>Profit</c:v></c:tx>`,
+		`<c:catAx><c:axId val="1"/><c:scaling><c:orientation val="minMax"/></c:scaling><c:delete val="0"/><c:axPos val="b"/><c:title><c:tx><c:rich><a:bodyPr/><a:p><a:r><a:t>Month</a:t>`,
+		`<c:valAx><c:axId val="2"/><c:scaling><c:orientation val="minMax"/></c:scaling><c:delete val="0"/><c:axPos val="l"/><c:majorGridlines/>`,
+		`<c:legend><c:legendPos val="r"/><c:overlay val="0"/></c:legend>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(str, e) {
+			t.Error("chart should contain", e, str)
+		}
+	}
+	str = readPart(workbook, "xl/charts/chart2.xml")
+	if !strings.Contains(str, `<c:pieChart><c:varyColors val="1"/>`) || strings.Contains(str, "c:valAx") || !strings.Contains(str, `<c:legendPos val="b"/>`) {
+		t.Error("pie chart should be written without axes.", str)
+	}
+	str = readPart(workbook, "xl/drawings/drawing1.xml")
+	expected = []string{
+		`<xdr:twoCellAnchor><xdr:from><xdr:col>4</xdr:col><xdr:colOff>0</xdr:colOff><xdr:row>1</xdr:row><xdr:rowOff>0</xdr:rowOff></xdr:from>` +
+			`<xdr:to><xdr:col>12</xdr:col><xdr:colOff>0</xdr:colOff><xdr:row>20</xdr:row><xdr:rowOff>0</xdr:rowOff></xdr:to><xdr:graphicFrame macro="">`,
+		`<xdr:cNvPr id="2" name="Chart 1"></xdr:cNvPr>`,
+		`<c:chart xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" r:id="rId1"></c:chart>`,
+		`<xdr:cNvPr id="3" name="Chart 2"></xdr:cNvPr>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(str, e) {
+			t.Error("drawing should contain", e, str)
+		}
+	}
+	if str = readPart(workbook, "xl/drawings/_rels/drawing1.xml.rels"); !strings.Contains(str, `Target="../charts/chart2.xml"`) {
+		t.Error("chart relationships should be written.", str)
+	}
+	if !workbook.types.hasOverride("/xl/charts/chart1.xml") || !workbook.types.hasOverride("/xl/charts/chart2.xml") {
+		t.Error("content types of charts should be added.")
+	}
+}
+
+func TestChartXML(t *testing.T) {
+	str, err := chartXML(ChartSpec{Type: ChartScatter, Legend: "none", Series: []ChartSeries{{Categories: "Data!$A$2:$A$9", Values: "Data!$B$2:$B$9"}}})
+	if err != nil {
+		t.Fatal("chart xml should be created.", err.Error())
+	}
+	if !strings.Contains(str, `<c:xVal><c:numRef><c:f>Data!$A$2:$A$9</c:f></c:numRef></c:xVal><c:yVal><c:numRef><c:f>Data!$B$2:$B$9</c:f></c:numRef></c:yVal>`) ||
+		strings.Count(str, "<c:valAx>") != 2 || strings.Contains(str, "c:legend") || !strings.Contains(str, `<c:autoTitleDeleted val="1"/>`) {
+		t.Error("scatter chart is invalid.", str)
+	}
+	str, _ = chartXML(ChartSpec{Type: ChartBar, Horizontal: true, Series: []ChartSeries{{Values: "Data!$B$2:$B$9"}}})
+	if !strings.Contains(str, `<c:barDir val="bar"/>`) || !strings.Contains(str, `<c:catAx><c:axId val="1"/><c:scaling><c:orientation val="minMax"/></c:scaling><c:delete val="0"/><c:axPos val="l"/>`) {
+		t.Error("horizontal bar chart is invalid.", str)
+	}
+	str, _ = chartXML(ChartSpec{Type: ChartLine, Series: []ChartSeries{{Values: "Data!$B$2:$B$9"}}})
+	if !strings.Contains(str, `<c:lineChart><c:grouping val="standard"/>`) || !strings.Contains(str, `<c:smooth val="0"/>`) {
+		t.Error("line chart is invalid.", str)
+	}
+	if _, err = chartXML(ChartSpec{Type: ChartLine, Legend: "x", Series: []ChartSeries{{Values: "Data!$B$2:$B$9"}}}); err == nil {
+		t.Error("invalid legend should be error.")
+	}
+}
@@ -0,0 +1,464 @@
+package excl
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// noteShapeType コメントの図形の種類
+const noteShapeType = `<v:shapetype id="_x0000_t202" coordsize="21600,21600" o:spt="202" path="m,l,21600r21600,l21600,xe">` +
+	`<v:stroke joinstyle="miter"/><v:path gradientshapeok="t" o:connecttype="rect"/></v:shapetype>`
+
+var (
+	// vmlShapePattern VMLの図形(v:shapetypeは含まない)
+	vmlShapePattern = regexp.MustCompile(`(?s)<v:shape\s.*?</v:shape>`)
+	// vmlShapeIDPattern VMLの図形のID
+	vmlShapeIDPattern = regexp.MustCompile(`(?:id|o:spid)="_x0000_s([0-9]+)"`)
+	// vmlIDMapPattern VMLの図形のIDの範囲
+	vmlIDMapPattern = regexp.MustCompile(`<o:idmap[^>]*data="([0-9]+)`)
+)
+
+const (
+	commentsContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.comments+xml"
+	vmlContentType      = "application/vnd.openxmlformats-officedocument.vmlDrawing"
+)
+
+// Comment セルのコメント
+type Comment struct {
+	Author string
+	Text   string
+}
+
+// sheetComments シートのコメントとVMLの情報
+type sheetComments struct {
+	path     string
+	vmlPath  string
+	vmlRID   string
+	comments *Tag
+	changed  bool
+	// created コメントのパーツを作成したか
+	created bool
+	// vmlCreated VMLを作成したか。既存のVMLは削除しない
+	vmlCreated bool
+}
+
+// SetComment set the comment to the cell. The comment of the cell is replaced if it exists.
+func (cell *Cell) SetComment(author string, text string) error {
+	sheet, err := cell.openedSheet()
+	if err != nil {
+		return err
+	}
+	c, err := sheet.getComments(true)
+	if err != nil {
+		return err
+	}
+	if c.vmlPath == "" {
+		sheet.createCommentsVML(c)
+	}
+	if sheet.workbook != nil {
+		sheet.workbook.types.addDefault("vml", vmlContentType)
+	}
+	comment := &Tag{Name: xml.Name{Local: "comment"}}
+	comment.setAttr("ref", cell.name())
+	comment.setAttr("authorId", strconv.Itoa(c.authorID(author)))
+	t := &Tag{Name: xml.Name{Local: "t"}, Children: []interface{}{xml.CharData(text)}}
+	t.setAttr("xml:space", "preserve")
+	comment.Children = []interface{}{&Tag{Name: xml.Name{Local: "text"}, Children: []interface{}{t}}}
+	list := c.comments.getChild("commentList")
+	if old := c.find(cell.name()); old != nil {
+		for i, child := range list.Children {
+			if child == old {
+				list.Children[i] = comment
+			}
+		}
+	} else {
+		list.Children = append(list.Children, comment)
+	}
+	c.changed = true
+	return nil
+}
+
+// GetComment get the comment of the cell. nil is returned if the cell has no comment.
+func (cell *Cell) GetComment() (*Comment, error) {
+	sheet, err := cell.openedSheet()
+	if err != nil {
+		return nil, err
+	}
+	c, err := sheet.getComments(false)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	tag := c.find(cell.name())
+	if tag == nil {
+		return nil, nil
+	}
+	comment := &Comment{}
+	id, _ := tag.getAttr("authorId")
+	index, _ := strconv.Atoi(id)
+	for i, author := range tagChildren(c.comments.getChild("authors"), "author") {
+		if i == index {
+			comment.Author = author.text()
+		}
+	}
+	if text := tag.getChild("text"); text != nil {
+		comment.Text = commentText(text)
+	}
+	return comment, nil
+}
+
+// DeleteComment delete the comment of the cell
+func (cell *Cell) DeleteComment() error {
+	sheet, err := cell.openedSheet()
+	if err != nil {
+		return err
+	}
+	c, err := sheet.getComments(false)
+	if err != nil {
+		return err
+	}
+	var tag *Tag
+	if c != nil {
+		tag = c.find(cell.name())
+	}
+	if tag == nil {
+		return errors.New("The cell [" + cell.name() + "] has no comment.")
+	}
+	list := c.comments.getChild("commentList")
+	var children []interface{}
+	for _, child := range list.Children {
+		if child != tag {
+			children = append(children, child)
+		}
+	}
+	list.Children = children
+	c.changed = true
+	return nil
+}
+
+// getComments シートのコメントを読み込む
+// コメントがない場合、createがtrueならコメントのパーツを作成し、falseならnilを返す
+// VMLはlegacyDrawingが参照するものを使う
+func (sheet *Sheet) getComments(create bool) (*sheetComments, error) {
+	if sheet.comments != nil {
+		return sheet.comments, nil
+	}
+	rels, err := sheet.getRels()
+	if err != nil {
+		return nil, err
+	}
+	var c *sheetComments
+	if rel := rels.findRel(relTypeComments); rel != nil {
+		c = &sheetComments{path: resolveTarget(sheet.sheetPath, rel.Target), comments: &Tag{}}
+		f, err := sheet.storage.Open(c.path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if err = xml.NewDecoder(f).Decode(c.comments); err != nil {
+			return nil, err
+		}
+		if c.comments.getChild("authors") == nil || c.comments.getChild("commentList") == nil {
+			return nil, errors.New("The file [" + c.path + "] is currupt.")
+		}
+		c.created = sheet.createdParts[c.path]
+	} else {
+		if !create {
+			return nil, nil
+		}
+		n := 1
+		for ; sheet.partExists(fmt.Sprintf("xl/comments%d.xml", n)); n++ {
+		}
+		c = &sheetComments{
+			path: fmt.Sprintf("xl/comments%d.xml", n),
+			comments: &Tag{
+				Name: xml.Name{Local: "comments"},
+				Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: "http://schemas.openxmlformats.org/spreadsheetml/2006/main"}},
+				Children: []interface{}{
+					&Tag{Name: xml.Name{Local: "authors"}},
+					&Tag{Name: xml.Name{Local: "commentList"}},
+				},
+			},
+			created: true,
+		}
+		rels.addRel(relTypeComments, relativePath(path.Dir(sheet.sheetPath), c.path), false)
+		if sheet.workbook != nil {
+			sheet.workbook.types.addOverride("/"+c.path, commentsContentType)
+		}
+	}
+	if legacy := sheet.worksheet.getChild("legacyDrawing"); legacy != nil {
+		c.vmlRID, _ = legacy.getAttr("r:id")
+		if vml := rels.getRel(c.vmlRID); vml != nil {
+			c.vmlPath = resolveTarget(sheet.sheetPath, vml.Target)
+			c.vmlCreated = sheet.createdParts[c.vmlPath]
+		}
+	}
+	if sheet.createdParts == nil {
+		sheet.createdParts = map[string]bool{}
+	}
+	sheet.createdParts[c.path] = c.created
+	if c.vmlPath != "" {
+		sheet.createdParts[c.vmlPath] = c.vmlCreated
+	}
+	sheet.comments = c
+	return c, nil
+}
+
+// createCommentsVML コメントを表示するVMLとlegacyDrawingを作成する
+func (sheet *Sheet) createCommentsVML(c *sheetComments) {
+	n := 1
+	for ; sheet.partExists(fmt.Sprintf("xl/drawings/vmlDrawing%d.vml", n)); n++ {
+	}
+	c.vmlPath = fmt.Sprintf("xl/drawings/vmlDrawing%d.vml", n)
+	c.vmlRID = sheet.rels.addRel(relTypeVMLDrawing, relativePath(path.Dir(sheet.sheetPath), c.vmlPath), false)
+	c.vmlCreated = true
+	sheet.createdParts[c.vmlPath] = true
+	legacy := &Tag{Name: xml.Name{Local: "legacyDrawing"}}
+	legacy.setAttr("r:id", c.vmlRID)
+	sheet.worksheet.insertChild(legacy, worksheetOrder)
+}
+
+// partExists パーツがストレージまたは[Content_Types].xmlに存在するか、まだ書き出していないパーツか確認する
+func (sheet *Sheet) partExists(name string) bool {
+	if sheet.storage.Exists(name) {
+		return true
+	}
+	if _, ok := sheet.createdParts[name]; ok {
+		return true
+	}
+	if sheet.workbook == nil {
+		return false
+	}
+	for _, s := range sheet.workbook.sheets {
+		if _, ok := s.createdParts[name]; ok {
+			return true
+		}
+	}
+	return sheet.workbook.types.hasOverride("/" + name)
+}
+
+// relativePath dirからパーツへの相対パスを取得する
+func relativePath(dir string, name string) string {
+	rel := ""
+	for ; dir != "." && dir != "" && !strings.HasPrefix(name, dir+"/"); dir = path.Dir(dir) {
+		rel += "../"
+	}
+	if dir == "." || dir == "" {
+		return rel + name
+	}
+	return rel + strings.TrimPrefix(name, dir+"/")
+}
+
+// closeComments 変更されたコメントとVMLを書き出す
+// コメントがなくなった場合、作成したパーツは削除し、既存のパーツはコメントを除いて書き出す
+func (sheet *Sheet) closeComments() error {
+	c := sheet.comments
+	if c == nil || !c.changed {
+		return nil
+	}
+	list := tagChildren(c.comments.getChild("commentList"), "comment")
+	if len(list) == 0 && c.created {
+		if err := sheet.removePart(c.path); err != nil {
+			return err
+		}
+		if sheet.workbook != nil {
+			sheet.workbook.types.removeOverride("/" + c.path)
+		}
+		if rel := sheet.rels.findRel(relTypeComments); rel != nil {
+			sheet.rels.removeRel(rel.ID)
+		}
+	} else if err := sheet.writeComments(c); err != nil {
+		return err
+	}
+	if c.vmlPath == "" {
+		return nil
+	}
+	if len(list) == 0 && c.vmlCreated {
+		if err := sheet.removePart(c.vmlPath); err != nil {
+			return err
+		}
+		sheet.rels.removeRel(c.vmlRID)
+		sheet.worksheet.removeChild("legacyDrawing")
+		return nil
+	}
+	return sheet.writeCommentsVML(c, list)
+}
+
+// writeComments コメントのパーツを書き出す
+func (sheet *Sheet) writeComments(c *sheetComments) error {
+	f, err := sheet.storage.Create(c.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	f.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n")
+	if err = xml.NewEncoder(f).Encode(c.comments); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// writeCommentsVML コメントの図形をVMLに書き出す
+// 作成したVMLは作り直し、既存のVMLはコメント以外の図形を残してコメントの図形を入れ替える
+func (sheet *Sheet) writeCommentsVML(c *sheetComments, list []*Tag) error {
+	var vml string
+	if c.vmlCreated {
+		n := 1
+		fmt.Sscanf(path.Base(c.vmlPath), "vmlDrawing%d.vml", &n)
+		vml = commentsVML(n, list)
+	} else {
+		f, err := sheet.storage.Open(c.vmlPath)
+		if err != nil {
+			return err
+		}
+		data, err := ioutil.ReadAll(f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if vml, err = mergeCommentsVML(string(data), list); err != nil {
+			return errors.New("The file [" + c.vmlPath + "] is currupt.")
+		}
+	}
+	f, err := sheet.storage.Create(c.vmlPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err = f.WriteString(vml); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// removePart ストレージにパーツがある場合は削除する
+func (sheet *Sheet) removePart(name string) error {
+	if !sheet.storage.Exists(name) {
+		return nil
+	}
+	return sheet.storage.Remove(name)
+}
+
+// find セルのコメントのタグを取得する
+func (c *sheetComments) find(ref string) *Tag {
+	for _, tag := range tagChildren(c.comments.getChild("commentList"), "comment") {
+		if r, _ := tag.getAttr("ref"); r == ref {
+			return tag
+		}
+	}
+	return nil
+}
+
+// authorID 作成者のIDを取得する。ない場合は追加する
+func (c *sheetComments) authorID(author string) int {
+	authors := c.comments.getChild("authors")
+	list := tagChildren(authors, "author")
+	for i, tag := range list {
+		if tag.text() == author {
+			return i
+		}
+	}
+	authors.Children = append(authors.Children, &Tag{Name: xml.Name{Local: "author"}, Children: []interface{}{xml.CharData(author)}})
+	return len(list)
+}
+
+// tagChildren 名前が一致する子タグを取得する
+func tagChildren(tag *Tag, name string) []*Tag {
+	var tags []*Tag
+	if tag == nil {
+		return tags
+	}
+	for _, child := range tag.Children {
+		if t, ok := child.(*Tag); ok && t.Name.Local == name {
+			tags = append(tags, t)
+		}
+	}
+	return tags
+}
+
+// commentText textタグ内の文字列を連結する(書式付きの場合はrタグごとのtを連結する)
+func commentText(tag *Tag) string {
+	var str string
+	for _, child := range tag.Children {
+		t, ok := child.(*Tag)
+		if !ok {
+			continue
+		}
+		switch t.Name.Local {
+		case "t":
+			str += t.text()
+		case "r":
+			if text := t.getChild("t"); text != nil {
+				str += text.text()
+			}
+		}
+	}
+	return str
+}
+
+// commentsVML コメントを表示するためのVMLを作成する
+func commentsVML(n int, comments []*Tag) string {
+	var b strings.Builder
+	b.WriteString(`<xml xmlns:v="urn:schemas-microsoft-com:vml" xmlns:o="urn:schemas-microsoft-com:office:office" xmlns:x="urn:schemas-microsoft-com:office:excel">`)
+	fmt.Fprintf(&b, `<o:shapelayout v:ext="edit"><o:idmap v:ext="edit" data="%d"/></o:shapelayout>`, n)
+	b.WriteString(noteShapeType)
+	b.WriteString(commentShapes(n*1024+1, comments))
+	b.WriteString(`</xml>`)
+	return b.String()
+}
+
+// mergeCommentsVML 既存のVMLのコメントの図形を入れ替える
+// VMLはXMLとして読めない場合があるため文字列のまま扱う
+func mergeCommentsVML(vml string, comments []*Tag) (string, error) {
+	if !strings.Contains(vml, "</xml>") {
+		return "", errors.New("The end of the vml is not found.")
+	}
+	vml = vmlShapePattern.ReplaceAllStringFunc(vml, func(shape string) string {
+		if strings.Contains(shape, `ObjectType="Note"`) {
+			return ""
+		}
+		return shape
+	})
+	next := 0
+	for _, m := range vmlShapeIDPattern.FindAllStringSubmatch(vml, -1) {
+		if id, _ := strconv.Atoi(m[1]); id >= next {
+			next = id + 1
+		}
+	}
+	if next == 0 {
+		n := 1
+		if m := vmlIDMapPattern.FindStringSubmatch(vml); m != nil {
+			n, _ = strconv.Atoi(m[1])
+		}
+		next = n*1024 + 1
+	}
+	shapes := commentShapes(next, comments)
+	if !strings.Contains(vml, `id="_x0000_t202"`) {
+		shapes = noteShapeType + shapes
+	}
+	end := strings.LastIndex(vml, "</xml>")
+	return vml[:end] + shapes + vml[end:], nil
+}
+
+// commentShapes コメントを表示する図形を作成する。図形のIDはidから順に振る
+func commentShapes(id int, comments []*Tag) string {
+	var b strings.Builder
+	for i, comment := range comments {
+		ref, _ := comment.getAttr("ref")
+		rowNo, colNo, err := parseCellRef(ref)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(&b, `<v:shape id="_x0000_s%d" type="#_x0000_t202" style="position:absolute;margin-left:59.25pt;margin-top:1.5pt;width:108pt;height:59.25pt;z-index:%d;visibility:hidden" fillcolor="#ffffe1" o:insetmode="auto">`, id+i, i+1)
+		b.WriteString(`<v:fill color2="#ffffe1"/><v:shadow on="t" color="black" obscured="t"/><v:path o:connecttype="none"/>`)
+		b.WriteString(`<v:textbox style="mso-direction-alt:auto"><div style="text-align:left"></div></v:textbox>`)
+		fmt.Fprintf(&b, `<x:ClientData ObjectType="Note"><x:MoveWithCells/><x:SizeWithCells/><x:Anchor>%d, 15, %d, 2, %d, 15, %d, 16</x:Anchor>`, colNo, rowNo-1, colNo+2, rowNo+3)
+		fmt.Fprintf(&b, `<x:AutoFill>False</x:AutoFill><x:Row>%d</x:Row><x:Column>%d</x:Column></x:ClientData></v:shape>`, rowNo-1, colNo-1)
+	}
+	return b.String()
+}
@@ -0,0 +1,184 @@
+package excl
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestSetComment(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	if err := sheet.GetRow(2).GetCell(2).SetComment("reviewer", "check this"); err != nil {
+		t.Fatal("comment should be set.", err.Error())
+	}
+	sheet.GetRow(3).GetCell(1).SetComment("auditor", " ok ")
+	sheet.GetRow(2).GetCell(2).SetComment("reviewer", "check this & that")
+	sheet.Close()
+
+	str := readPart(workbook, "xl/comments1.xml")
+	expected := `<comments xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><authors><author>reviewer</author><author>auditor</author></authors><commentList>` +
+		`<comment ref="B2" authorId="0"><text><t xml:space="preserve">check this &amp; that</t></text></comment><comment ref="A3" authorId="1"><text><t xml:space="preserve"> ok </t></text></comment></commentList></comments>`
+	if !strings.Contains(str, expected) {
+		t.Error("comments should be written.", str)
+	}
+	str = readPart(workbook, "xl/drawings/vmlDrawing1.vml")
+	if strings.Count(str, "<v:shape ") != 2 || !strings.Contains(str, `<x:Row>1</x:Row><x:Column>1</x:Column>`) || !strings.Contains(str, `<o:idmap v:ext="edit" data="1"/>`) {
+		t.Error("vml should be written.", str)
+	}
+	str = readPart(workbook, "xl/worksheets/_rels/sheet1.xml.rels")
+	if !strings.Contains(str, `Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/comments" Target="../comments1.xml"`) ||
+		!strings.Contains(str, `Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/vmlDrawing" Target="../drawings/vmlDrawing1.vml"`) {
+		t.Error("relationships should be written.", str)
+	}
+	if str = readPart(workbook, "xl/worksheets/sheet1.xml"); !strings.Contains(str, `</sheetData><legacyDrawing r:id="rId2"></legacyDrawing></worksheet>`) {
+		t.Error("legacyDrawing should be written.", str)
+	}
+	if !workbook.types.hasOverride("/xl/comments1.xml") {
+		t.Error("content type of comments should be added.")
+	}
+
+	// 他のセルを変更してもコメントは残る
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	sheet.GetRow(5).SetString("changed", 1)
+	sheet.Close()
+	if str = readPart(workbook, "xl/comments1.xml"); !strings.Contains(str, "check this &amp; that") {
+		t.Error("comments should be kept.", str)
+	}
+
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	comment, err := sheet.GetRow(2).GetCell(2).GetComment()
+	if err != nil || comment == nil || comment.Author != "reviewer" || comment.Text != "check this & that" {
+		t.Error("comment should be read.", comment, err)
+	}
+	if comment, _ = sheet.GetRow(3).GetCell(1).GetComment(); comment == nil || comment.Text != " ok " {
+		t.Error("spaces of comment should be kept.", comment)
+	}
+	if comment, _ = sheet.GetRow(1).GetCell(1).GetComment(); comment != nil {
+		t.Error("cell without comment should return nil.", comment)
+	}
+	if err = sheet.GetRow(1).GetCell(1).DeleteComment(); err == nil {
+		t.Error("cell without comment should be error.")
+	}
+	sheet.GetRow(2).GetCell(2).DeleteComment()
+	sheet.GetRow(3).GetCell(1).DeleteComment()
+	sheet.Close()
+	if workbook.storage.Exists("xl/comments1.xml") || workbook.storage.Exists("xl/drawings/vmlDrawing1.vml") {
+		t.Error("comment parts should be removed.")
+	}
+	if workbook.types.hasOverride("/xl/comments1.xml") {
+		t.Error("content type of comments should be removed.")
+	}
+	if str = readPart(workbook, "xl/worksheets/sheet1.xml"); strings.Contains(str, "legacyDrawing") {
+		t.Error("legacyDrawing should be removed.", str)
+	}
+}
+
+func TestCommentsOfSheets(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet1, _ := workbook.OpenSheet("Sheet1")
+	sheet2, _ := workbook.OpenSheet("Sheet2")
+	sheet1.GetRow(1).GetCell(1).SetComment("a", "first")
+	sheet2.GetRow(1).GetCell(1).SetComment("b", "second")
+	sheet1.Close()
+	sheet2.Close()
+	if !strings.Contains(readPart(workbook, "xl/comments1.xml"), "first") || !strings.Contains(readPart(workbook, "xl/comments2.xml"), "second") {
+		t.Error("each sheet should have its own comments part.")
+	}
+	if !strings.Contains(readPart(workbook, "xl/drawings/vmlDrawing2.vml"), `data="2"`) {
+		t.Error("shape ids of vml should not be duplicated.")
+	}
+}
+
+func TestRelativePath(t *testing.T) {
+	if p := relativePath("xl/worksheets", "xl/comments1.xml"); p != "../comments1.xml" {
+		t.Error("path should be ../comments1.xml but", p)
+	}
+	if p := relativePath("xl/worksheets", "xl/worksheets/a.xml"); p != "a.xml" {
+		t.Error("path should be a.xml but", p)
+	}
+	if p := relativePath("xl/worksheets", "docProps/app.xml"); p != "../../docProps/app.xml" {
+		t.Error("path should be ../../docProps/app.xml but", p)
+	}
+}
+
+func TestCommentsWithExistingVML(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	controls := `<xml xmlns:v="urn:schemas-microsoft-com:vml" xmlns:o="urn:schemas-microsoft-com:office:office" xmlns:x="urn:schemas-microsoft-com:office:excel">` +
+		`<o:shapelayout v:ext="edit"><o:idmap v:ext="edit" data="1"/></o:shapelayout>` +
+		`<v:shapetype id="_x0000_t201" coordsize="21600,21600" o:spt="201" path="m,l,21600r21600,l21600,xe"></v:shapetype>` +
+		`<v:shape id="_x0000_s1025" type="#_x0000_t201" style="position:absolute"><x:ClientData ObjectType="Button"><x:FmlaMacro>Run</x:FmlaMacro></x:ClientData></v:shape></xml>`
+	header := `<xml xmlns:v="urn:schemas-microsoft-com:vml"><v:shape id="LH" type="#_x0000_t75"><v:imagedata o:relid="rId1"/></v:shape></xml>`
+	for _, part := range []struct{ name, body string }{{"xl/drawings/vmlDrawing1.vml", controls}, {"xl/drawings/vmlDrawing2.vml", header}} {
+		f, _ := workbook.storage.Create(part.name)
+		f.WriteString(part.body)
+		f.Close()
+	}
+	sheet1, _ := workbook.OpenSheet("Sheet1")
+	sheet2, _ := workbook.OpenSheet("Sheet2")
+	rels, _ := sheet1.getRels()
+	legacy := &Tag{Name: xml.Name{Local: "legacyDrawing"}}
+	legacy.setAttr("r:id", rels.addRel(relTypeVMLDrawing, "../drawings/vmlDrawing1.vml", false))
+	sheet1.worksheet.insertChild(legacy, worksheetOrder)
+	rels, _ = sheet2.getRels()
+	legacy = &Tag{Name: xml.Name{Local: "legacyDrawingHF"}}
+	legacy.setAttr("r:id", rels.addRel(relTypeVMLDrawing, "../drawings/vmlDrawing2.vml", false))
+	sheet2.worksheet.insertChild(legacy, worksheetOrder)
+	sheet1.Close()
+	sheet2.Close()
+
+	// フォームコントロールのVMLにコメントを追加する
+	sheet1, _ = workbook.OpenSheet("Sheet1")
+	sheet1.GetRow(2).GetCell(2).SetComment("reviewer", "check")
+	sheet1.Close()
+	str := readPart(workbook, "xl/drawings/vmlDrawing1.vml")
+	if !strings.Contains(str, `ObjectType="Button"`) || !strings.Contains(str, `<v:shape id="_x0000_s1026" type="#_x0000_t202"`) ||
+		strings.Count(str, `id="_x0000_t202"`) != 1 || !strings.HasSuffix(str, "</v:shape></xml>") {
+		t.Error("comment shape should be merged into the existing vml.", str)
+	}
+	if str = readPart(workbook, "xl/worksheets/_rels/sheet1.xml.rels"); strings.Count(str, relTypeVMLDrawing) != 1 {
+		t.Error("existing vml should be reused.", str)
+	}
+	sheet1, _ = workbook.OpenSheet("Sheet1")
+	sheet1.GetRow(2).GetCell(2).SetComment("reviewer", "changed")
+	sheet1.Close()
+	if str = readPart(workbook, "xl/drawings/vmlDrawing1.vml"); strings.Count(str, `ObjectType="Note"`) != 1 {
+		t.Error("comment shape should be replaced.", str)
+	}
+	sheet1, _ = workbook.OpenSheet("Sheet1")
+	sheet1.GetRow(2).GetCell(2).DeleteComment()
+	sheet1.Close()
+	if workbook.storage.Exists("xl/comments1.xml") {
+		t.Error("created comments part should be removed.")
+	}
+	if str = readPart(workbook, "xl/drawings/vmlDrawing1.vml"); !strings.Contains(str, `ObjectType="Button"`) || strings.Contains(str, `ObjectType="Note"`) {
+		t.Error("existing vml should be kept without comment shapes.", str)
+	}
+	if str = readPart(workbook, "xl/worksheets/sheet1.xml"); !strings.Contains(str, "<legacyDrawing ") {
+		t.Error("legacyDrawing of existing vml should be kept.", str)
+	}
+
+	// ヘッダーとフッターのVMLは使わずにVMLを作成する
+	sheet2, _ = workbook.OpenSheet("Sheet2")
+	sheet2.GetRow(1).GetCell(1).SetComment("reviewer", "new")
+	sheet2.Close()
+	if str = readPart(workbook, "xl/drawings/vmlDrawing2.vml"); str != header {
+		t.Error("vml of header and footer should not be changed.", str)
+	}
+	if str = readPart(workbook, "xl/drawings/vmlDrawing3.vml"); !strings.Contains(str, `ObjectType="Note"`) {
+		t.Error("new vml should be created.", str)
+	}
+	str = readPart(workbook, "xl/worksheets/sheet2.xml")
+	if !strings.Contains(str, `<legacyDrawing r:id="rId3"></legacyDrawing><legacyDrawingHF r:id="rId1"></legacyDrawingHF>`) {
+		t.Error("legacyDrawing should be added before legacyDrawingHF.", str)
+	}
+	sheet2, _ = workbook.OpenSheet("Sheet2")
+	sheet2.GetRow(1).GetCell(1).DeleteComment()
+	sheet2.Close()
+	if workbook.storage.Exists("xl/drawings/vmlDrawing3.vml") || !workbook.storage.Exists("xl/drawings/vmlDrawing2.vml") {
+		t.Error("only created vml should be removed.")
+	}
+}
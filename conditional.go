@@ -0,0 +1,212 @@
+package excl
+
+import (
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ConditionalType 条件付き書式の種類
+type ConditionalType string
+
+const (
+	// ConditionalCellIs セルの値を比較する
+	ConditionalCellIs ConditionalType = "cellIs"
+	// ConditionalExpression 数式がTRUEのセル
+	ConditionalExpression ConditionalType = "expression"
+	// ConditionalTop10 上位または下位の値
+	ConditionalTop10 ConditionalType = "top10"
+	// ConditionalDuplicateValues 重複する値
+	ConditionalDuplicateValues ConditionalType = "duplicateValues"
+	// ConditionalColorScale カラースケール
+	ConditionalColorScale ConditionalType = "colorScale"
+	// ConditionalDataBar データバー
+	ConditionalDataBar ConditionalType = "dataBar"
+	// ConditionalIconSet アイコンセット
+	ConditionalIconSet ConditionalType = "iconSet"
+)
+
+// ConditionalFormat 条件付き書式のルール
+type ConditionalFormat struct {
+	// Type 条件付き書式の種類
+	Type ConditionalType
+	// DxfID Styles.AddDxfで追加した書式。CellIs、Expression、Top10、DuplicateValuesで使用する
+	DxfID int
+	// Operator CellIsの比較演算子("greaterThan"、"between"など)
+	Operator string
+	// Formulas CellIsの比較する値(betweenとnotBetweenは2つ)、Expressionの数式
+	// 数式は範囲の左上のセルを基準にした相対参照で指定する
+	Formulas []string
+	// Rank Top10の件数(0の場合は10)
+	Rank int
+	// Percent Top10のRankを百分率として扱う
+	Percent bool
+	// Bottom Top10で下位の値を対象にする
+	Bottom bool
+	// Colors ColorScaleの最小、(中間、)最大の色。DataBarのバーの色
+	Colors []string
+	// IconSet アイコンセットの名前(空の場合は"3TrafficLights1")
+	IconSet string
+	// StopIfTrue 条件を満たした場合に優先度の低いルールを適用しない
+	StopIfTrue bool
+}
+
+// AddConditionalFormat add the conditional formatting rule to the range such as "A2:H100"
+// Multiple ranges are separated by spaces. Rules added later have lower priority.
+func (sheet *Sheet) AddConditionalFormat(ref string, rule ConditionalFormat) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	sqref, err := parseSqref(ref)
+	if err != nil {
+		return err
+	}
+	cfRule, err := cfRuleTag(rule)
+	if err != nil {
+		return err
+	}
+	cfRule.setAttr("priority", strconv.Itoa(sheet.maxPriority()+1))
+	tag := &Tag{Name: xml.Name{Local: "conditionalFormatting"}}
+	tag.setAttr("sqref", sqref)
+	tag.Children = []interface{}{cfRule}
+	sheet.worksheet.addChild(tag, worksheetOrder)
+	return nil
+}
+
+// maxPriority シートの条件付き書式の最も低い優先度を取得する
+func (sheet *Sheet) maxPriority() int {
+	max := 0
+	for _, child := range sheet.worksheet.Children {
+		tag, ok := child.(*Tag)
+		if !ok || tag.Name.Local != "conditionalFormatting" {
+			continue
+		}
+		for _, c := range tag.Children {
+			if rule, ok := c.(*Tag); ok && rule.Name.Local == "cfRule" {
+				str, _ := rule.getAttr("priority")
+				if p, _ := strconv.Atoi(str); p > max {
+					max = p
+				}
+			}
+		}
+	}
+	return max
+}
+
+// cfRuleTag 条件付き書式のルールからcfRuleタグを作成する
+func cfRuleTag(rule ConditionalFormat) (*Tag, error) {
+	tag := &Tag{Name: xml.Name{Local: "cfRule"}}
+	tag.setAttr("type", string(rule.Type))
+	switch rule.Type {
+	case ConditionalCellIs:
+		switch rule.Operator {
+		case "between", "notBetween":
+			if len(rule.Formulas) != 2 {
+				return nil, errors.New("The operator [" + rule.Operator + "] needs 2 formulas.")
+			}
+		case "equal", "notEqual", "greaterThan", "lessThan", "greaterThanOrEqual", "lessThanOrEqual":
+			if len(rule.Formulas) != 1 {
+				return nil, errors.New("The operator [" + rule.Operator + "] needs 1 formula.")
+			}
+		default:
+			return nil, errors.New("The operator [" + rule.Operator + "] is invalid.")
+		}
+		tag.setAttr("dxfId", strconv.Itoa(rule.DxfID))
+		tag.setAttr("operator", rule.Operator)
+		tag.Children = formulaTags(rule.Formulas)
+	case ConditionalExpression:
+		if len(rule.Formulas) != 1 {
+			return nil, errors.New("The expression needs 1 formula.")
+		}
+		tag.setAttr("dxfId", strconv.Itoa(rule.DxfID))
+		tag.Children = formulaTags(rule.Formulas)
+	case ConditionalTop10:
+		rank := rule.Rank
+		if rank == 0 {
+			rank = 10
+		}
+		if rank < 0 || (rule.Percent && rank > 100) {
+			return nil, errors.New("The rank [" + strconv.Itoa(rule.Rank) + "] is invalid.")
+		}
+		tag.setAttr("dxfId", strconv.Itoa(rule.DxfID))
+		if rule.Percent {
+			tag.setAttr("percent", "1")
+		}
+		if rule.Bottom {
+			tag.setAttr("bottom", "1")
+		}
+		tag.setAttr("rank", strconv.Itoa(rank))
+	case ConditionalDuplicateValues:
+		tag.setAttr("dxfId", strconv.Itoa(rule.DxfID))
+	case ConditionalColorScale:
+		if len(rule.Colors) != 2 && len(rule.Colors) != 3 {
+			return nil, errors.New("The color scale needs 2 or 3 colors.")
+		}
+		scale := &Tag{Name: xml.Name{Local: "colorScale"}}
+		scale.Children = append(scale.Children, cfvoTag("min", ""))
+		if len(rule.Colors) == 3 {
+			scale.Children = append(scale.Children, cfvoTag("percentile", "50"))
+		}
+		scale.Children = append(scale.Children, cfvoTag("max", ""))
+		for _, color := range rule.Colors {
+			scale.Children = append(scale.Children, colorTag(color))
+		}
+		tag.Children = []interface{}{scale}
+	case ConditionalDataBar:
+		if len(rule.Colors) != 1 {
+			return nil, errors.New("The data bar needs 1 color.")
+		}
+		bar := &Tag{Name: xml.Name{Local: "dataBar"}}
+		bar.Children = []interface{}{cfvoTag("min", ""), cfvoTag("max", ""), colorTag(rule.Colors[0])}
+		tag.Children = []interface{}{bar}
+	case ConditionalIconSet:
+		name := rule.IconSet
+		if name == "" {
+			name = "3TrafficLights1"
+		}
+		count, _ := strconv.Atoi(name[:1])
+		if count < 3 || count > 5 {
+			return nil, errors.New("The icon set [" + name + "] is invalid.")
+		}
+		icons := &Tag{Name: xml.Name{Local: "iconSet"}}
+		icons.setAttr("iconSet", name)
+		for i := 0; i < count; i++ {
+			icons.Children = append(icons.Children, cfvoTag("percent", strconv.Itoa((i*100+count/2)/count)))
+		}
+		tag.Children = []interface{}{icons}
+	default:
+		return nil, errors.New("The conditional format type [" + string(rule.Type) + "] is invalid.")
+	}
+	if rule.StopIfTrue {
+		tag.setAttr("stopIfTrue", "1")
+	}
+	return tag, nil
+}
+
+// formulaTags 数式からformulaタグを作成する
+func formulaTags(formulas []string) []interface{} {
+	var tags []interface{}
+	for _, formula := range formulas {
+		formula = strings.TrimPrefix(formula, "=")
+		tags = append(tags, &Tag{Name: xml.Name{Local: "formula"}, Children: []interface{}{xml.CharData(formula)}})
+	}
+	return tags
+}
+
+// cfvoTag 条件付き書式の基準値のタグを作成する
+func cfvoTag(typ string, val string) *Tag {
+	tag := &Tag{Name: xml.Name{Local: "cfvo"}}
+	tag.setAttr("type", typ)
+	if val != "" {
+		tag.setAttr("val", val)
+	}
+	return tag
+}
+
+// colorTag 色のタグを作成する
+func colorTag(color string) *Tag {
+	tag := &Tag{Name: xml.Name{Local: "color"}}
+	tag.setAttr("rgb", color)
+	return tag
+}
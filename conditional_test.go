@@ -0,0 +1,59 @@
+package excl
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestAddConditionalFormat(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	sheet.SetAutoFilter("A1:H100")
+	sheet.AddDataValidation("B2:B100", DataValidation{Type: ValidationList, Values: []string{"a"}})
+	red := workbook.Styles.AddDxf(Font{Color: "FF9C0006"}, "FFFFC7CE", Border{}, "")
+	rules := []ConditionalFormat{
+		{Type: ConditionalExpression, DxfID: red, Formulas: []string{"=$D2<TODAY()"}},
+		{Type: ConditionalCellIs, DxfID: red, Operator: "between", Formulas: []string{"1", "10"}, StopIfTrue: true},
+		{Type: ConditionalTop10, DxfID: red, Rank: 5, Percent: true, Bottom: true},
+		{Type: ConditionalDuplicateValues, DxfID: red},
+		{Type: ConditionalColorScale, Colors: []string{"FFF8696B", "FFFFEB84", "FF63BE7B"}},
+		{Type: ConditionalDataBar, Colors: []string{"FF638EC6"}},
+		{Type: ConditionalIconSet, IconSet: "3Arrows"},
+	}
+	refs := []string{"A2:H100", "E2:E100", "F2:F100", "A2:A100", "G2:G100", "H2:H100", "C2:C100"}
+	for i, rule := range rules {
+		if err := sheet.AddConditionalFormat(refs[i], rule); err != nil {
+			t.Fatal("conditional format should be added.", i, err.Error())
+		}
+	}
+	if err := sheet.AddConditionalFormat("A1", ConditionalFormat{Type: ConditionalCellIs, Operator: "between", Formulas: []string{"1"}}); err == nil {
+		t.Error("between with 1 formula should be error.")
+	}
+	if err := sheet.AddConditionalFormat("A1", ConditionalFormat{Type: ConditionalColorScale, Colors: []string{"FF000000"}}); err == nil {
+		t.Error("color scale with 1 color should be error.")
+	}
+	if err := sheet.AddConditionalFormat("A1", ConditionalFormat{Type: "unknown"}); err == nil {
+		t.Error("invalid type should be error.")
+	}
+	sheet.Close()
+
+	f, _ := workbook.storage.Open("xl/worksheets/sheet1.xml")
+	b, _ := ioutil.ReadAll(f)
+	f.Close()
+	str := string(b)
+	expected := []string{
+		`<conditionalFormatting sqref="A2:H100"><cfRule type="expression" dxfId="0" priority="1"><formula>$D2&lt;TODAY()</formula></cfRule></conditionalFormatting>`,
+		`<conditionalFormatting sqref="E2:E100"><cfRule type="cellIs" dxfId="0" operator="between" stopIfTrue="1" priority="2"><formula>1</formula><formula>10</formula></cfRule></conditionalFormatting>`,
+		`<conditionalFormatting sqref="F2:F100"><cfRule type="top10" dxfId="0" percent="1" bottom="1" rank="5" priority="3"></cfRule></conditionalFormatting>`,
+		`<conditionalFormatting sqref="A2:A100"><cfRule type="duplicateValues" dxfId="0" priority="4"></cfRule></conditionalFormatting>`,
+		`<conditionalFormatting sqref="G2:G100"><cfRule type="colorScale" priority="5"><colorScale><cfvo type="min"></cfvo><cfvo type="percentile" val="50"></cfvo><cfvo type="max"></cfvo>` +
+			`<color rgb="FFF8696B"></color><color rgb="FFFFEB84"></color><color rgb="FF63BE7B"></color></colorScale></cfRule></conditionalFormatting>`,
+		`<conditionalFormatting sqref="H2:H100"><cfRule type="dataBar" priority="6"><dataBar><cfvo type="min"></cfvo><cfvo type="max"></cfvo><color rgb="FF638EC6"></color></dataBar></cfRule></conditionalFormatting>`,
+		`<conditionalFormatting sqref="C2:C100"><cfRule type="iconSet" priority="7"><iconSet iconSet="3Arrows"><cfvo type="percent" val="0"></cfvo><cfvo type="percent" val="33"></cfvo><cfvo type="percent" val="67"></cfvo></iconSet></cfRule></conditionalFormatting>`,
+	}
+	if !strings.Contains(str, `</autoFilter>`+strings.Join(expected, "")+`<dataValidations`) {
+		t.Error("conditionalFormatting should be written between autoFilter and dataValidations.", str)
+	}
+}
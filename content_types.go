@@ -4,16 +4,18 @@ import (
 	"encoding/xml"
 	"fmt"
 	"io/ioutil"
-	"os"
-	"path/filepath"
+	"strings"
 )
 
 // ContentTypes ContentTypesの情報を保持
 type ContentTypes struct {
-	path  string
-	types *ContentTypesXML
+	storage Storage
+	types   *ContentTypesXML
 }
 
+// contentTypesName [Content_Types].xmlのパス
+const contentTypesName = "[Content_Types].xml"
+
 // ContentTypesXML [Content_Types].xmlファイルを読み込む
 type ContentTypesXML struct {
 	XMLName   xml.Name          `xml:"Types"`
@@ -35,9 +37,8 @@ type contentDefault struct {
 }
 
 // createContentTypes [Content_Types].xmlファイルを作成する
-func createContentTypes(dir string) error {
-	path := filepath.Join(dir, "[Content_Types].xml")
-	f, err := os.Create(path)
+func createContentTypes(storage Storage) error {
+	f, err := storage.Create(contentTypesName)
 	if err != nil {
 		return err
 	}
@@ -55,8 +56,12 @@ func createContentTypes(dir string) error {
 
 // OpenContentTypes [Content_Types].xmlファイルを開き構造体に読み込む
 func OpenContentTypes(dir string) (*ContentTypes, error) {
-	path := filepath.Join(dir, "[Content_Types].xml")
-	f, err := os.Open(path)
+	return openContentTypes(newDirStorage(dir))
+}
+
+// openContentTypes [Content_Types].xmlファイルをストレージから開く
+func openContentTypes(storage Storage) (*ContentTypes, error) {
+	f, err := storage.Open(contentTypesName)
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +76,7 @@ func OpenContentTypes(dir string) (*ContentTypes, error) {
 		return nil, err
 	}
 	f.Close()
-	types := &ContentTypes{path, &v}
+	types := &ContentTypes{storage, &v}
 	return types, nil
 }
 
@@ -80,7 +85,7 @@ func (types *ContentTypes) Close() error {
 	if types == nil {
 		return nil
 	}
-	f, err := os.Create(types.path)
+	f, err := types.storage.Create(contentTypesName)
 	if err != nil {
 		return err
 	}
@@ -138,3 +143,49 @@ func (types *ContentTypes) addSharedString() {
 		ContentType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sharedStrings+xml"}
 	types.types.Overrides = append(types.types.Overrides, override)
 }
+
+// addDefault 拡張子のContentTypeを追加する。すでにある場合は何もしない
+func (types *ContentTypes) addDefault(extension string, contentType string) {
+	for _, d := range types.types.Defaults {
+		if strings.EqualFold(d.Extension, extension) {
+			return
+		}
+	}
+	def := contentDefault{
+		XMLName:     xml.Name{Space: "", Local: "Default"},
+		Extension:   extension,
+		ContentType: contentType}
+	types.types.Defaults = append(types.types.Defaults, def)
+}
+
+// hasOverride パーツのContentTypeがあるか確認する
+func (types *ContentTypes) hasOverride(partName string) bool {
+	for _, override := range types.types.Overrides {
+		if override.PartName == partName {
+			return true
+		}
+	}
+	return false
+}
+
+// addOverride パーツのContentTypeを追加する。すでにある場合は何もしない
+func (types *ContentTypes) addOverride(partName string, contentType string) {
+	if types.hasOverride(partName) {
+		return
+	}
+	override := contentOverride{
+		XMLName:     xml.Name{Space: "", Local: "Override"},
+		PartName:    partName,
+		ContentType: contentType}
+	types.types.Overrides = append(types.types.Overrides, override)
+}
+
+// removeOverride パーツのContentTypeを削除する
+func (types *ContentTypes) removeOverride(partName string) {
+	for i, override := range types.types.Overrides {
+		if override.PartName == partName {
+			types.types.Overrides = append(types.types.Overrides[:i], types.types.Overrides[i+1:]...)
+			return
+		}
+	}
+}
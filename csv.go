@@ -0,0 +1,212 @@
+package excl
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/transform"
+)
+
+// CSVImportOptions ImportCSVの設定
+type CSVImportOptions struct {
+	// StartCell 書き込みを開始するセル(空の場合はA1)
+	StartCell string
+	// Comma 区切り文字(0の場合はカンマ)
+	Comma rune
+	// Encoding CSVの文字コード(nilの場合はUTF-8)
+	// 例: japanese.ShiftJIS
+	Encoding encoding.Encoding
+	// DateLayouts 日付として解釈するtime.Parseのレイアウト(nilの場合はDefaultDateLayouts)
+	DateLayouts []string
+	// NoInference 型を推定せずにすべて文字列として書き込む
+	NoInference bool
+}
+
+// CSVExportOptions ExportCSVの設定
+type CSVExportOptions struct {
+	// Comma 区切り文字(0の場合はカンマ)
+	Comma rune
+	// Encoding CSVの文字コード(nilの場合はUTF-8)
+	Encoding encoding.Encoding
+	// Raw 表示形式を適用せずにセルの値をそのまま出力する
+	Raw bool
+	// UseCRLF 改行をCRLFにする
+	UseCRLF bool
+}
+
+// DefaultDateLayouts ImportCSVで日付として解釈するレイアウト
+var DefaultDateLayouts = []string{
+	"2006-01-02",
+	"2006/1/2",
+	"2006-01-02 15:04:05",
+	"2006/1/2 15:04:05",
+	"2006-01-02 15:04",
+	"2006/1/2 15:04",
+	"2006-01-02T15:04:05",
+}
+
+// csvNumberPattern 数値として扱う文字列(先頭が0の"0123"などは文字列として扱う)
+var csvNumberPattern = regexp.MustCompile(`^[+-]?(0|[1-9][0-9]*)?(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// ImportCSV CSVをシートに書き込む
+// 数値、日付、TRUE/FALSEは型を推定して書き込み、空の項目はセルを作成しない
+func (sheet *Sheet) ImportCSV(r io.Reader, opts CSVImportOptions) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	rowNo, colNo := 1, 1
+	if opts.StartCell != "" {
+		var err error
+		if rowNo, colNo, err = parseCellRef(opts.StartCell); err != nil {
+			return err
+		}
+	}
+	if opts.Encoding != nil {
+		r = transform.NewReader(r, opts.Encoding.NewDecoder())
+	} else {
+		r = skipBOM(r)
+	}
+	layouts := opts.DateLayouts
+	if layouts == nil {
+		layouts = DefaultDateLayouts
+	}
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+	for ; ; rowNo++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		row := sheet.GetRow(rowNo)
+		for i, value := range record {
+			if value == "" {
+				continue
+			}
+			if opts.NoInference {
+				row.SetString(value, colNo+i)
+			} else {
+				setInferredValue(row, colNo+i, value, layouts)
+			}
+		}
+	}
+}
+
+// skipBOM UTF-8のBOMを読み飛ばす
+func skipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	if b, err := br.Peek(3); err == nil && string(b) == "\xef\xbb\xbf" {
+		br.Discard(3)
+	}
+	return br
+}
+
+// setInferredValue 文字列から型を推定してセルに書き込む
+func setInferredValue(row *Row, colNo int, value string, layouts []string) *Cell {
+	trimmed := strings.TrimSpace(value)
+	switch strings.ToUpper(trimmed) {
+	case "TRUE":
+		return row.SetBool(true, colNo)
+	case "FALSE":
+		return row.SetBool(false, colNo)
+	}
+	if trimmed != "" && trimmed != "." && csvNumberPattern.MatchString(trimmed) {
+		if _, err := strconv.ParseFloat(trimmed, 64); err == nil {
+			return row.SetNumber(trimmed, colNo)
+		}
+	}
+	for _, layout := range layouts {
+		t, err := time.ParseInLocation(layout, trimmed, time.UTC)
+		if err != nil {
+			continue
+		}
+		cell := row.SetDate(t, colNo)
+		if t.Hour() != 0 || t.Minute() != 0 || t.Second() != 0 {
+			cell.SetStyle(&Style{NumFmtID: 22})
+		}
+		return cell
+	}
+	return row.SetString(value, colNo)
+}
+
+// ExportCSV シートの内容をCSVとして書き出す
+// A1から値のある最後の列までを出力し、値のない行は空行になる
+// 開いているシートで行を出力済みの場合はエラーになる
+func (sheet *Sheet) ExportCSV(w io.Writer, opts CSVExportOptions) error {
+	maxColNo := 0
+	err := sheet.eachRow(func(row *Row) error {
+		for _, cell := range row.GetCells() {
+			if cell.colNo > maxColNo && cell.Type() != CellTypeBlank {
+				maxColNo = cell.colNo
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	var encoder *transform.Writer
+	if opts.Encoding != nil {
+		encoder = transform.NewWriter(w, opts.Encoding.NewEncoder())
+		w = encoder
+	}
+	writer := csv.NewWriter(w)
+	writer.UseCRLF = opts.UseCRLF
+	if opts.Comma != 0 {
+		writer.Comma = opts.Comma
+	}
+	lastRowNo := 0
+	record := make([]string, maxColNo)
+	err = sheet.eachRow(func(row *Row) error {
+		for ; lastRowNo < row.rowID-1; lastRowNo++ {
+			if err := writer.Write(make([]string, maxColNo)); err != nil {
+				return err
+			}
+		}
+		lastRowNo = row.rowID
+		for i := range record {
+			record[i] = ""
+		}
+		for _, cell := range row.GetCells() {
+			if cell.colNo > maxColNo {
+				continue
+			}
+			var str string
+			var err error
+			if opts.Raw {
+				str, err = cell.GetString()
+			} else {
+				str, err = cell.GetFormattedString()
+			}
+			if err != nil {
+				return errors.New(cellName(row.rowID, cell.colNo) + ": " + err.Error())
+			}
+			record[cell.colNo-1] = str
+		}
+		return writer.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+	writer.Flush()
+	if err = writer.Error(); err != nil {
+		return err
+	}
+	if encoder != nil {
+		return encoder.Close()
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package excl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+)
+
+func TestImportCSV(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	data := "\xef\xbb\xbfname,amount,code,date,paid\n" +
+		"\"apple, red\",1234.5,0123,2017/4/1,TRUE\n" +
+		"orange,-1e3,,2017-04-01 13:05:00,false\n"
+	if err := sheet.ImportCSV(strings.NewReader(data), CSVImportOptions{StartCell: "B2"}); err != nil {
+		t.Fatal("csv should be imported.", err.Error())
+	}
+	if v, _ := sheet.GetRow(2).GetCell(2).GetString(); v != "name" {
+		t.Error("B2 should be name but", v)
+	}
+	row := sheet.GetRow(3)
+	if v, _ := row.GetCell(2).GetString(); v != "apple, red" {
+		t.Error("B3 should be [apple, red] but", v)
+	}
+	if row.GetCell(3).Type() != CellTypeNumber {
+		t.Error("C3 should be number.")
+	}
+	if row.GetCell(4).Type() != CellTypeSharedString {
+		t.Error("D3 should be string because of leading zero.")
+	}
+	if v, _ := row.GetCell(5).GetTime(); !v.Equal(time.Date(2017, 4, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("E3 should be 2017-04-01 but", v)
+	}
+	if v, err := row.GetCell(6).GetBool(); err != nil || !v || row.GetCell(6).Type() != CellTypeBool {
+		t.Error("F3 should be true.")
+	}
+	row = sheet.GetRow(4)
+	if v, _ := row.GetCell(3).GetNumber(); v != -1000 {
+		t.Error("C4 should be -1000 but", v)
+	}
+	if row.findCell(4) != nil {
+		t.Error("empty field should not create a cell.")
+	}
+	if row.GetCell(5).GetStyle().NumFmtID != 22 {
+		t.Error("date time should have number format 22.")
+	}
+
+	if err := sheet.ImportCSV(strings.NewReader("1,2"), CSVImportOptions{StartCell: "A10", NoInference: true}); err != nil {
+		t.Fatal("csv should be imported.", err.Error())
+	}
+	if sheet.GetRow(10).GetCell(1).Type() != CellTypeSharedString {
+		t.Error("value should be string without inference.")
+	}
+	if err := sheet.ImportCSV(strings.NewReader("a"), CSVImportOptions{StartCell: "1A"}); err == nil {
+		t.Error("invalid start cell should be error.")
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	sjis, _, _ := transform.String(japanese.ShiftJIS.NewEncoder(), "品名;金額;日付\nりんご;1234.5;2017/4/1\n")
+	err := sheet.ImportCSV(strings.NewReader(sjis), CSVImportOptions{StartCell: "A2", Comma: ';', Encoding: japanese.ShiftJIS})
+	if err != nil {
+		t.Fatal("csv should be imported.", err.Error())
+	}
+	if v, _ := sheet.GetRow(3).GetCell(1).GetString(); v != "りんご" {
+		t.Error("A3 should be りんご but", v)
+	}
+	sheet.GetRow(3).GetCell(2).SetNumFmt("#,##0.00")
+
+	var buf bytes.Buffer
+	if err = sheet.ExportCSV(&buf, CSVExportOptions{}); err != nil {
+		t.Fatal("csv should be exported.", err.Error())
+	}
+	expected := ",,\n品名,金額,日付\nりんご,\"1,234.50\",2017-04-01\n"
+	if buf.String() != expected {
+		t.Error("csv should be", expected, "but", buf.String())
+	}
+
+	sheet.Close()
+	buf.Reset()
+	if err = sheet.ExportCSV(&buf, CSVExportOptions{Raw: true, Encoding: japanese.ShiftJIS, UseCRLF: true}); err != nil {
+		t.Fatal("csv should be exported.", err.Error())
+	}
+	str, _, _ := transform.String(japanese.ShiftJIS.NewDecoder(), buf.String())
+	expected = ",,\r\n品名,金額,日付\r\nりんご,1234.5,2017-04-01T00:00:00\r\n"
+	if str != expected {
+		t.Error("csv should be", expected, "but", str)
+	}
+}
+
+func TestExportCSVAfterOutput(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	sheet.GetRow(1).SetString("a", 1)
+	sheet.GetRow(2).SetString("b", 1)
+	sheet.OutputThroughRowNo(1)
+	var buf bytes.Buffer
+	if err := sheet.ExportCSV(&buf, CSVExportOptions{}); err == nil {
+		t.Error("csv of the sheet whose rows are output should be error.", buf.String())
+	}
+	sheet.Close()
+	buf.Reset()
+	if err := sheet.ExportCSV(&buf, CSVExportOptions{}); err != nil || buf.String() != "a\nb\n" {
+		t.Error("csv should be exported after the sheet is closed.", buf.String(), err)
+	}
+}
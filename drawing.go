@@ -0,0 +1,154 @@
+package excl
+
+import (
+	"encoding/xml"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	drawingContentType = "application/vnd.openxmlformats-officedocument.drawing+xml"
+	relTypeDrawing     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/drawing"
+	// emuPerPixel 1ピクセルあたりのEMU
+	emuPerPixel = 9525
+)
+
+// sheetDrawing シートの描画パーツ(drawingN.xml)の情報
+type sheetDrawing struct {
+	path string
+	tag  *Tag
+	rels *SheetRels
+}
+
+// getDrawing シートの描画パーツを取得する。ない場合は作成する
+func (sheet *Sheet) getDrawing() (*sheetDrawing, error) {
+	if sheet.drawing != nil {
+		return sheet.drawing, nil
+	}
+	rels, err := sheet.getRels()
+	if err != nil {
+		return nil, err
+	}
+	d := &sheetDrawing{}
+	if rel := rels.findRel(relTypeDrawing); rel != nil {
+		d.path = resolveTarget(sheet.sheetPath, rel.Target)
+		f, err := sheet.storage.Open(d.path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		d.tag = &Tag{}
+		if err = xml.NewDecoder(f).Decode(d.tag); err != nil {
+			return nil, err
+		}
+	} else {
+		n := 1
+		for ; sheet.partExists(fmt.Sprintf("xl/drawings/drawing%d.xml", n)); n++ {
+		}
+		d.path = fmt.Sprintf("xl/drawings/drawing%d.xml", n)
+		d.tag = &Tag{Name: xml.Name{Local: "xdr:wsDr"}}
+		d.tag.setAttr("xmlns:xdr", "http://schemas.openxmlformats.org/drawingml/2006/spreadsheetDrawing")
+		d.tag.setAttr("xmlns:a", "http://schemas.openxmlformats.org/drawingml/2006/main")
+		rid := rels.addRel(relTypeDrawing, relativePath(path.Dir(sheet.sheetPath), d.path), false)
+		drawing := &Tag{Name: xml.Name{Local: "drawing"}}
+		drawing.setAttr("r:id", rid)
+		sheet.worksheet.insertChild(drawing, worksheetOrder)
+	}
+	if d.rels, err = openSheetRels(sheet.storage, d.path); err != nil {
+		return nil, err
+	}
+	if sheet.workbook != nil {
+		sheet.workbook.types.addOverride("/"+d.path, drawingContentType)
+	}
+	sheet.drawing = d
+	return d, nil
+}
+
+// closeDrawing 描画パーツとリレーションを書き出す
+func (sheet *Sheet) closeDrawing() error {
+	d := sheet.drawing
+	if d == nil {
+		return nil
+	}
+	f, err := sheet.storage.Create(d.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	f.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n")
+	if err = xml.NewEncoder(f).Encode(d.tag); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	return d.rels.Close()
+}
+
+// nextShapeID 描画パーツ内で使われていない図形のIDを取得する
+func (d *sheetDrawing) nextShapeID() int {
+	max := 1
+	var walk func(tag *Tag)
+	walk = func(tag *Tag) {
+		if strings.HasSuffix(tag.Name.Local, "cNvPr") {
+			if id, err := tag.getAttr("id"); err == nil {
+				if n, _ := strconv.Atoi(id); n > max {
+					max = n
+				}
+			}
+		}
+		for _, child := range tag.Children {
+			if t, ok := child.(*Tag); ok {
+				walk(t)
+			}
+		}
+	}
+	walk(d.tag)
+	return max + 1
+}
+
+// drawingAnchor 図形を配置するアンカー
+// toがない場合はoneCellAnchorでextの大きさになり、ある場合はtwoCellAnchorになる
+type drawingAnchor struct {
+	fromRow, fromCol, fromOffX, fromOffY int
+	toRow, toCol, toOffX, toOffY         int
+	twoCell                              bool
+	cx, cy                               int
+}
+
+// addAnchor 図形をアンカーで描画パーツに追加する
+func (d *sheetDrawing) addAnchor(anchor drawingAnchor, content *Tag) {
+	tag := &Tag{Name: xml.Name{Local: "xdr:oneCellAnchor"}}
+	if anchor.twoCell {
+		tag.Name.Local = "xdr:twoCellAnchor"
+	}
+	tag.Children = append(tag.Children, markerTag("xdr:from", anchor.fromRow, anchor.fromCol, anchor.fromOffX, anchor.fromOffY))
+	if anchor.twoCell {
+		tag.Children = append(tag.Children, markerTag("xdr:to", anchor.toRow, anchor.toCol, anchor.toOffX, anchor.toOffY))
+	} else {
+		ext := &Tag{Name: xml.Name{Local: "xdr:ext"}}
+		ext.setAttr("cx", strconv.Itoa(anchor.cx))
+		ext.setAttr("cy", strconv.Itoa(anchor.cy))
+		tag.Children = append(tag.Children, ext)
+	}
+	tag.Children = append(tag.Children, content, &Tag{Name: xml.Name{Local: "xdr:clientData"}})
+	d.tag.Children = append(d.tag.Children, tag)
+}
+
+// markerTag 行と列(1から)とEMUのオフセットからfromまたはtoのタグを作成する
+func markerTag(name string, rowNo int, colNo int, offX int, offY int) *Tag {
+	textTag := func(name string, value int) *Tag {
+		return &Tag{Name: xml.Name{Local: name}, Children: []interface{}{xml.CharData(strconv.Itoa(value))}}
+	}
+	return &Tag{
+		Name: xml.Name{Local: name},
+		Children: []interface{}{
+			textTag("xdr:col", colNo-1),
+			textTag("xdr:colOff", offX),
+			textTag("xdr:row", rowNo-1),
+			textTag("xdr:rowOff", offY),
+		},
+	}
+}
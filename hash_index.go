@@ -0,0 +1,193 @@
+package excl
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"io"
+)
+
+const (
+	// defaultHashIndexLimit メモリ上に保持するハッシュの最大件数
+	defaultHashIndexLimit = 1 << 18
+	// hashSlotSize ファイル上の1スロットのサイズ(ハッシュ値8byte + インデックス4byte)
+	hashSlotSize = 12
+)
+
+// hashIndex 文字列のハッシュ値からインデックスを引くための表
+// 件数がlimitを超えるとオープンアドレス法のハッシュ表としてファイルに退避する
+type hashIndex struct {
+	memory  map[uint64]int
+	limit   int
+	storage Storage
+	path    string
+	file    File
+	slots   int64
+	used    int64
+}
+
+// newHashIndex 新しいhashIndexを作成する
+func newHashIndex(storage Storage, path string, limit int) *hashIndex {
+	return &hashIndex{memory: map[uint64]int{}, limit: limit, storage: storage, path: path}
+}
+
+// hashString 文字列のハッシュ値を取得する
+func hashString(text string) uint64 {
+	h := fnv.New64a()
+	io.WriteString(h, text)
+	return h.Sum64()
+}
+
+// get ハッシュ値に対応するインデックスを取得する
+func (index *hashIndex) get(hash uint64) (int, bool) {
+	if index.file == nil {
+		i, ok := index.memory[hash]
+		return i, ok
+	}
+	slot := make([]byte, hashSlotSize)
+	for pos := int64(hash) & (index.slots - 1); ; pos = (pos + 1) & (index.slots - 1) {
+		if _, err := index.file.ReadAt(slot, pos*hashSlotSize); err != nil {
+			return 0, false
+		}
+		i := binary.LittleEndian.Uint32(slot[8:])
+		if i == 0 {
+			return 0, false
+		}
+		if binary.LittleEndian.Uint64(slot) == hash {
+			return int(i) - 1, true
+		}
+	}
+}
+
+// put ハッシュ値とインデックスを追加する
+func (index *hashIndex) put(hash uint64, i int) error {
+	if index.file == nil {
+		index.memory[hash] = i
+		if len(index.memory) > index.limit {
+			return index.spill()
+		}
+		return nil
+	}
+	if (index.used+1)*2 > index.slots {
+		if err := index.grow(); err != nil {
+			return err
+		}
+	}
+	if err := index.insert(index.file, index.slots, hash, uint32(i+1)); err != nil {
+		return err
+	}
+	index.used++
+	return nil
+}
+
+// insert ファイル上のハッシュ表に書き込む
+func (index *hashIndex) insert(f File, slots int64, hash uint64, value uint32) error {
+	slot := make([]byte, hashSlotSize)
+	for pos := int64(hash) & (slots - 1); ; pos = (pos + 1) & (slots - 1) {
+		if _, err := f.ReadAt(slot, pos*hashSlotSize); err != nil {
+			return err
+		}
+		if binary.LittleEndian.Uint32(slot[8:]) != 0 {
+			continue
+		}
+		binary.LittleEndian.PutUint64(slot, hash)
+		binary.LittleEndian.PutUint32(slot[8:], value)
+		_, err := f.WriteAt(slot, pos*hashSlotSize)
+		return err
+	}
+}
+
+// createTable 空のハッシュ表ファイルを作成する
+func (index *hashIndex) createTable(path string, slots int64) (File, error) {
+	f, err := index.storage.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = io.CopyN(f, zeroReader{}, slots*hashSlotSize); err != nil {
+		f.Close()
+		index.storage.Remove(path)
+		return nil, err
+	}
+	return f, nil
+}
+
+// spill メモリ上のハッシュをファイルに退避する
+func (index *hashIndex) spill() error {
+	slots := int64(1)
+	for slots < int64(len(index.memory))*4 {
+		slots <<= 1
+	}
+	f, err := index.createTable(index.path, slots)
+	if err != nil {
+		return err
+	}
+	for hash, i := range index.memory {
+		if err = index.insert(f, slots, hash, uint32(i+1)); err != nil {
+			f.Close()
+			index.storage.Remove(index.path)
+			return err
+		}
+	}
+	index.file = f
+	index.slots = slots
+	index.used = int64(len(index.memory))
+	index.memory = nil
+	return nil
+}
+
+// grow ハッシュ表のサイズを2倍にする
+func (index *hashIndex) grow() error {
+	slots := index.slots * 2
+	path := index.path + ".tmp"
+	f, err := index.createTable(path, slots)
+	if err != nil {
+		return err
+	}
+	buf := make([]byte, hashSlotSize*1024)
+	for offset := int64(0); offset < index.slots*hashSlotSize; offset += int64(len(buf)) {
+		n, err := index.file.ReadAt(buf, offset)
+		if err != nil && err != io.EOF {
+			f.Close()
+			index.storage.Remove(path)
+			return err
+		}
+		for i := 0; i+hashSlotSize <= n; i += hashSlotSize {
+			value := binary.LittleEndian.Uint32(buf[i+8:])
+			if value == 0 {
+				continue
+			}
+			if err = index.insert(f, slots, binary.LittleEndian.Uint64(buf[i:]), value); err != nil {
+				f.Close()
+				index.storage.Remove(path)
+				return err
+			}
+		}
+	}
+	index.file.Close()
+	if err = index.storage.Rename(path, index.path); err != nil {
+		f.Close()
+		return err
+	}
+	index.file = f
+	index.slots = slots
+	return nil
+}
+
+// close ハッシュ表ファイルを削除する
+func (index *hashIndex) close() {
+	if index == nil || index.file == nil {
+		return
+	}
+	index.file.Close()
+	index.storage.Remove(index.path)
+	index.file = nil
+}
+
+// zeroReader 0を返し続けるReader
+type zeroReader struct{}
+
+func (zeroReader) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 0
+	}
+	return len(b), nil
+}
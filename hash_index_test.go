@@ -0,0 +1,40 @@
+package excl
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestHashIndex(t *testing.T) {
+	os.Mkdir("temp/xl", 0755)
+	defer os.RemoveAll("temp/xl")
+	index := newHashIndex(newDirStorage("temp"), "xl/hash.dat", 10)
+	for i := 0; i < 1000; i++ {
+		if err := index.put(hashString(strconv.Itoa(i)), i); err != nil {
+			t.Fatal("hash should be added.", err.Error())
+		}
+		if i == 10 && index.file == nil {
+			t.Error("hash index should be spilled to file.")
+		}
+	}
+	if index.memory != nil {
+		t.Error("memory should be released.")
+	}
+	for i := 0; i < 1000; i++ {
+		if v, ok := index.get(hashString(strconv.Itoa(i))); !ok || v != i {
+			t.Error("index should be", i, "but", v, ok)
+		}
+	}
+	if _, ok := index.get(hashString("not found")); ok {
+		t.Error("hash should not be found.")
+	}
+	if index.used*2 > index.slots {
+		t.Error("hash table should be grown.", index.used, index.slots)
+	}
+	index.close()
+	if isFileExist(filepath.Join("temp", "xl", "hash.dat")) {
+		t.Error("hash file should be removed.")
+	}
+}
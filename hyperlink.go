@@ -0,0 +1,184 @@
+package excl
+
+import (
+	"encoding/xml"
+	"errors"
+)
+
+// Hyperlink セルのハイパーリンク
+type Hyperlink struct {
+	// Ref リンクを設定したセルまたは範囲
+	Ref string
+	// URL 外部のリンク先
+	URL string
+	// Location ブック内のリンク先("Sheet2!A1"など)
+	Location string
+	// Tooltip マウスを重ねた場合に表示する文字列
+	Tooltip string
+	// Display 表示する文字列
+	Display string
+}
+
+// SetHyperlink set the link to the url such as "https://example.com" or "mailto:user@example.com"
+func (cell *Cell) SetHyperlink(url string, tooltip string) error {
+	if url == "" {
+		return errors.New("The url of the hyperlink is empty.")
+	}
+	sheet, err := cell.openedSheet()
+	if err != nil {
+		return err
+	}
+	rels, err := sheet.getRels()
+	if err != nil {
+		return err
+	}
+	sheet.removeHyperlink(cell.name())
+	tag := &Tag{Name: xml.Name{Local: "hyperlink"}}
+	tag.setAttr("ref", cell.name())
+	tag.setAttr("r:id", rels.addRel(relTypeHyperlink, url, true))
+	if tooltip != "" {
+		tag.setAttr("tooltip", tooltip)
+	}
+	sheet.addHyperlink(tag)
+	return nil
+}
+
+// SetInternalLink set the link to the location in the workbook such as "Sheet2!A1"
+func (cell *Cell) SetInternalLink(location string) error {
+	if location == "" {
+		return errors.New("The location of the hyperlink is empty.")
+	}
+	sheet, err := cell.openedSheet()
+	if err != nil {
+		return err
+	}
+	sheet.removeHyperlink(cell.name())
+	tag := &Tag{Name: xml.Name{Local: "hyperlink"}}
+	tag.setAttr("ref", cell.name())
+	tag.setAttr("location", location)
+	tag.setAttr("display", location)
+	sheet.addHyperlink(tag)
+	return nil
+}
+
+// GetHyperlink get the hyperlink of the cell. nil is returned if the cell has no link.
+func (cell *Cell) GetHyperlink() (*Hyperlink, error) {
+	sheet, err := cell.openedSheet()
+	if err != nil {
+		return nil, err
+	}
+	links, err := sheet.Hyperlinks()
+	if err != nil {
+		return nil, err
+	}
+	rowNo, colNo, _ := parseCellRef(cell.name())
+	for i := range links {
+		if r, err := parseRange(links[i].Ref); err == nil && r.contains(rowNo, colNo) {
+			return &links[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// RemoveHyperlink remove the hyperlink of the cell
+func (cell *Cell) RemoveHyperlink() error {
+	sheet, err := cell.openedSheet()
+	if err != nil {
+		return err
+	}
+	if !sheet.removeHyperlink(cell.name()) {
+		return errors.New("The cell [" + cell.name() + "] has no hyperlink.")
+	}
+	return nil
+}
+
+// Hyperlinks get the hyperlinks of the sheet
+func (sheet *Sheet) Hyperlinks() ([]Hyperlink, error) {
+	if !sheet.opened {
+		return nil, errors.New("The sheet is not opened.")
+	}
+	hyperlinks := sheet.worksheet.getChild("hyperlinks")
+	if hyperlinks == nil {
+		return nil, nil
+	}
+	var links []Hyperlink
+	for _, child := range hyperlinks.Children {
+		tag, ok := child.(*Tag)
+		if !ok || tag.Name.Local != "hyperlink" {
+			continue
+		}
+		link := Hyperlink{}
+		link.Ref, _ = tag.getAttr("ref")
+		link.Location, _ = tag.getAttr("location")
+		link.Tooltip, _ = tag.getAttr("tooltip")
+		link.Display, _ = tag.getAttr("display")
+		if rid, err := tag.getAttr("r:id"); err == nil {
+			rels, err := sheet.getRels()
+			if err != nil {
+				return nil, err
+			}
+			if rel := rels.getRel(rid); rel != nil {
+				link.URL = rel.Target
+			}
+		}
+		links = append(links, link)
+	}
+	return links, nil
+}
+
+// addHyperlink hyperlinksタグにリンクを追加する
+func (sheet *Sheet) addHyperlink(tag *Tag) {
+	hyperlinks := sheet.worksheet.getChild("hyperlinks")
+	if hyperlinks == nil {
+		hyperlinks = &Tag{Name: xml.Name{Local: "hyperlinks"}}
+		sheet.worksheet.insertChild(hyperlinks, worksheetOrder)
+	}
+	hyperlinks.Children = append(hyperlinks.Children, tag)
+}
+
+// removeHyperlink セルを含むリンクを削除する。削除した場合はtrueを返す
+func (sheet *Sheet) removeHyperlink(ref string) bool {
+	hyperlinks := sheet.worksheet.getChild("hyperlinks")
+	if hyperlinks == nil {
+		return false
+	}
+	rowNo, colNo, _ := parseCellRef(ref)
+	var children []interface{}
+	removed := false
+	count := 0
+	for _, child := range hyperlinks.Children {
+		if tag, ok := child.(*Tag); ok && tag.Name.Local == "hyperlink" {
+			count++
+			linkRef, _ := tag.getAttr("ref")
+			if r, err := parseRange(linkRef); err == nil && r.contains(rowNo, colNo) {
+				if rid, err := tag.getAttr("r:id"); err == nil {
+					if rels, err := sheet.getRels(); err == nil {
+						rels.removeRel(rid)
+					}
+				}
+				removed = true
+				count--
+				continue
+			}
+		}
+		children = append(children, child)
+	}
+	hyperlinks.Children = children
+	if count == 0 {
+		sheet.worksheet.removeChild("hyperlinks")
+	}
+	return removed
+}
+
+// openedSheet セルを含む開いているシートを取得する
+func (cell *Cell) openedSheet() (*Sheet, error) {
+	if cell.row == nil || cell.row.sheet == nil || !cell.row.sheet.opened {
+		return nil, errors.New("The sheet of the cell is not opened.")
+	}
+	return cell.row.sheet, nil
+}
+
+// name "B3"のようなセルの位置を取得する
+func (cell *Cell) name() string {
+	return cellName(cell.row.rowID, cell.colNo)
+}
@@ -0,0 +1,98 @@
+package excl
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func readPart(workbook *Workbook, name string) string {
+	f, err := workbook.storage.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	b, _ := ioutil.ReadAll(f)
+	return string(b)
+}
+
+func TestSetHyperlink(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	workbook.OpenSheet("Sheet2")
+	cell := sheet.GetRow(1).SetString("home", 1)
+	if err := cell.SetHyperlink("https://example.com/?a=1&b=2", "open"); err != nil {
+		t.Fatal("hyperlink should be set.", err.Error())
+	}
+	if err := sheet.GetRow(2).GetCell(1).SetInternalLink("Sheet2!A1"); err != nil {
+		t.Fatal("internal link should be set.", err.Error())
+	}
+	sheet.GetRow(3).GetCell(1).SetHyperlink("mailto:user@example.com", "")
+	if err := sheet.GetRow(4).GetCell(1).SetHyperlink("", ""); err == nil {
+		t.Error("empty url should be error.")
+	}
+	if err := cell.SetHyperlink("https://example.com/new", ""); err != nil {
+		t.Error("hyperlink should be replaced.", err.Error())
+	}
+	sheet.Close()
+
+	str := readPart(workbook, "xl/worksheets/sheet1.xml")
+	expected := `</sheetData><hyperlinks><hyperlink ref="A2" location="Sheet2!A1" display="Sheet2!A1"></hyperlink>` +
+		`<hyperlink ref="A3" r:id="rId2"></hyperlink><hyperlink ref="A1" r:id="rId3"></hyperlink></hyperlinks>`
+	if !strings.Contains(str, expected) {
+		t.Error("hyperlinks should be written after sheetData.", str)
+	}
+	str = readPart(workbook, "xl/worksheets/_rels/sheet1.xml.rels")
+	expected = `<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="mailto:user@example.com" TargetMode="External"></Relationship>` +
+		`<Relationship Id="rId3" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://example.com/new" TargetMode="External"></Relationship>`
+	if !strings.Contains(str, expected) {
+		t.Error("relationships should be written.", str)
+	}
+	if !strings.Contains(workbook.types.types.Defaults[0].Extension, "rels") || len(workbook.types.types.Defaults) != 1 {
+		t.Error("content type of rels should be registered once.", workbook.types.types.Defaults)
+	}
+
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	links, err := sheet.Hyperlinks()
+	if err != nil || len(links) != 3 {
+		t.Fatal("hyperlinks should be read.", links, err)
+	}
+	link, _ := sheet.GetRow(1).GetCell(1).GetHyperlink()
+	if link == nil || link.URL != "https://example.com/new" || link.Ref != "A1" {
+		t.Error("hyperlink of A1 is invalid.", link)
+	}
+	link, _ = sheet.GetRow(2).GetCell(1).GetHyperlink()
+	if link == nil || link.Location != "Sheet2!A1" || link.URL != "" {
+		t.Error("internal link of A2 is invalid.", link)
+	}
+	if link, _ = sheet.GetRow(5).GetCell(1).GetHyperlink(); link != nil {
+		t.Error("cell without link should return nil.", link)
+	}
+	for _, rowNo := range []int{1, 2, 3} {
+		if err := sheet.GetRow(rowNo).GetCell(1).RemoveHyperlink(); err != nil {
+			t.Error("hyperlink should be removed.", err.Error())
+		}
+	}
+	if err := sheet.GetRow(1).GetCell(1).RemoveHyperlink(); err == nil {
+		t.Error("cell without link should be error.")
+	}
+	sheet.Close()
+	if str = readPart(workbook, "xl/worksheets/sheet1.xml"); strings.Contains(str, "hyperlinks") {
+		t.Error("hyperlinks should be removed.", str)
+	}
+	if workbook.storage.Exists("xl/worksheets/_rels/sheet1.xml.rels") {
+		t.Error("empty relationships file should be removed.")
+	}
+}
+
+func TestHyperlinkClosedSheet(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	cell := sheet.GetRow(1).GetCell(1)
+	sheet.Close()
+	if err := cell.SetHyperlink("https://example.com", ""); err == nil {
+		t.Error("hyperlink should not be set to the closed sheet.")
+	}
+}
@@ -0,0 +1,187 @@
+package excl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	// PNGとJPEGの大きさを取得するために登録する
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+)
+
+const relTypeImage = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+
+// ImageAnchor 画像の配置
+type ImageAnchor struct {
+	// From 画像の左上のセル("B2"など)
+	From string
+	// OffsetX Fromのセルの左端からのピクセル数
+	OffsetX int
+	// OffsetY Fromのセルの上端からのピクセル数
+	OffsetY int
+	// To 画像の右下のセル。指定した場合はセルに合わせて画像の大きさが変わる
+	To string
+	// ToOffsetX Toのセルの左端からのピクセル数
+	ToOffsetX int
+	// ToOffsetY Toのセルの上端からのピクセル数
+	ToOffsetY int
+	// ScaleX 横方向の倍率(0の場合は1)。Toを指定しない場合に使用する
+	ScaleX float64
+	// ScaleY 縦方向の倍率(0の場合は1)。Toを指定しない場合に使用する
+	ScaleY float64
+	// Name 画像の名前(空の場合は"Picture N")
+	Name string
+}
+
+// AddImage add the PNG or JPEG image file to the sheet
+func (sheet *Sheet) AddImage(name string, anchor ImageAnchor) error {
+	f, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return sheet.AddImageReader(f, anchor)
+}
+
+// AddImageReader add the PNG or JPEG image to the sheet
+func (sheet *Sheet) AddImageReader(r io.Reader, anchor ImageAnchor) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return errors.New("The image is not PNG or JPEG.")
+	}
+	var ext, contentType string
+	switch format {
+	case "png":
+		ext, contentType = "png", "image/png"
+	case "jpeg":
+		ext, contentType = "jpeg", "image/jpeg"
+	default:
+		return errors.New("The image format [" + format + "] is not supported.")
+	}
+	a, err := imageAnchor(anchor, config.Width, config.Height)
+	if err != nil {
+		return err
+	}
+	d, err := sheet.getDrawing()
+	if err != nil {
+		return err
+	}
+
+	n := 1
+	for ; sheet.storage.Exists(fmt.Sprintf("xl/media/image%d.%s", n, ext)); n++ {
+	}
+	mediaPath := fmt.Sprintf("xl/media/image%d.%s", n, ext)
+	f, err := sheet.storage.Create(mediaPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if sheet.workbook != nil {
+		sheet.workbook.types.addDefault(ext, contentType)
+	}
+	rid := d.rels.addRel(relTypeImage, relativePath(path.Dir(d.path), mediaPath), false)
+	id := d.nextShapeID()
+	if anchor.Name == "" {
+		anchor.Name = "Picture " + strconv.Itoa(id-1)
+	}
+	d.addAnchor(a, picTag(id, anchor.Name, rid, a.cx, a.cy))
+	return nil
+}
+
+// imageAnchor 画像の配置からアンカーを作成する
+func imageAnchor(anchor ImageAnchor, width int, height int) (drawingAnchor, error) {
+	a := drawingAnchor{}
+	var err error
+	if a.fromRow, a.fromCol, err = parseCellRef(anchor.From); err != nil {
+		return a, err
+	}
+	if anchor.OffsetX < 0 || anchor.OffsetY < 0 || anchor.ToOffsetX < 0 || anchor.ToOffsetY < 0 {
+		return a, errors.New("The offset of the image should not be negative.")
+	}
+	if anchor.ScaleX < 0 || anchor.ScaleY < 0 {
+		return a, errors.New("The scale of the image should not be negative.")
+	}
+	a.fromOffX = anchor.OffsetX * emuPerPixel
+	a.fromOffY = anchor.OffsetY * emuPerPixel
+	scaleX, scaleY := anchor.ScaleX, anchor.ScaleY
+	if scaleX == 0 {
+		scaleX = 1
+	}
+	if scaleY == 0 {
+		scaleY = 1
+	}
+	a.cx = int(float64(width*emuPerPixel) * scaleX)
+	a.cy = int(float64(height*emuPerPixel) * scaleY)
+	if anchor.To == "" {
+		return a, nil
+	}
+	if a.toRow, a.toCol, err = parseCellRef(anchor.To); err != nil {
+		return a, err
+	}
+	if a.toRow < a.fromRow || a.toCol < a.fromCol {
+		return a, errors.New("The cell [" + anchor.To + "] should be below and right of the cell [" + anchor.From + "].")
+	}
+	a.toOffX = anchor.ToOffsetX * emuPerPixel
+	a.toOffY = anchor.ToOffsetY * emuPerPixel
+	a.twoCell = true
+	return a, nil
+}
+
+// picTag 画像のxdr:picタグを作成する
+func picTag(id int, name string, rid string, cx int, cy int) *Tag {
+	cNvPr := &Tag{Name: xml.Name{Local: "xdr:cNvPr"}}
+	cNvPr.setAttr("id", strconv.Itoa(id))
+	cNvPr.setAttr("name", name)
+	picLocks := &Tag{Name: xml.Name{Local: "a:picLocks"}}
+	picLocks.setAttr("noChangeAspect", "1")
+	nvPicPr := &Tag{
+		Name: xml.Name{Local: "xdr:nvPicPr"},
+		Children: []interface{}{
+			cNvPr,
+			&Tag{Name: xml.Name{Local: "xdr:cNvPicPr"}, Children: []interface{}{picLocks}},
+		},
+	}
+
+	blip := &Tag{Name: xml.Name{Local: "a:blip"}}
+	blip.setAttr("xmlns:r", "http://schemas.openxmlformats.org/officeDocument/2006/relationships")
+	blip.setAttr("r:embed", rid)
+	stretch := &Tag{Name: xml.Name{Local: "a:stretch"}, Children: []interface{}{&Tag{Name: xml.Name{Local: "a:fillRect"}}}}
+	blipFill := &Tag{Name: xml.Name{Local: "xdr:blipFill"}, Children: []interface{}{blip, stretch}}
+
+	off := &Tag{Name: xml.Name{Local: "a:off"}}
+	off.setAttr("x", "0")
+	off.setAttr("y", "0")
+	ext := &Tag{Name: xml.Name{Local: "a:ext"}}
+	ext.setAttr("cx", strconv.Itoa(cx))
+	ext.setAttr("cy", strconv.Itoa(cy))
+	geom := &Tag{Name: xml.Name{Local: "a:prstGeom"}, Children: []interface{}{&Tag{Name: xml.Name{Local: "a:avLst"}}}}
+	geom.setAttr("prst", "rect")
+	spPr := &Tag{
+		Name: xml.Name{Local: "xdr:spPr"},
+		Children: []interface{}{
+			&Tag{Name: xml.Name{Local: "a:xfrm"}, Children: []interface{}{off, ext}},
+			geom,
+		},
+	}
+	return &Tag{Name: xml.Name{Local: "xdr:pic"}, Children: []interface{}{nvPicPr, blipFill, spPr}}
+}
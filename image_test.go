@@ -0,0 +1,90 @@
+package excl
+
+import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAddImage(t *testing.T) {
+	var pngData, jpegData bytes.Buffer
+	png.Encode(&pngData, image.NewRGBA(image.Rect(0, 0, 40, 20)))
+	jpeg.Encode(&jpegData, image.NewRGBA(image.Rect(0, 0, 10, 10)), nil)
+	imagePath := filepath.Join("temp", "logo.png")
+	f, _ := os.Create(imagePath)
+	f.Write(pngData.Bytes())
+	f.Close()
+	defer os.Remove(imagePath)
+
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	if err := sheet.AddImage(imagePath, ImageAnchor{From: "B2", OffsetX: 2, OffsetY: 3, ScaleX: 0.5}); err != nil {
+		t.Fatal("image should be added.", err.Error())
+	}
+	if err := sheet.AddImageReader(bytes.NewReader(jpegData.Bytes()), ImageAnchor{From: "D2", To: "F8", Name: "signature"}); err != nil {
+		t.Fatal("image should be added.", err.Error())
+	}
+	if err := sheet.AddImageReader(strings.NewReader("GIF89a"), ImageAnchor{From: "A1"}); err == nil {
+		t.Error("unsupported image should be error.")
+	}
+	if err := sheet.AddImageReader(bytes.NewReader(pngData.Bytes()), ImageAnchor{From: "D2", To: "A1"}); err == nil {
+		t.Error("invalid anchor should be error.")
+	}
+	sheet.Close()
+
+	str := readPart(workbook, "xl/drawings/drawing1.xml")
+	expected := `<xdr:oneCellAnchor><xdr:from><xdr:col>1</xdr:col><xdr:colOff>19050</xdr:colOff><xdr:row>1</xdr:row><xdr:rowOff>28575</xdr:rowOff></xdr:from>` +
+		`<xdr:ext cx="190500" cy="190500"></xdr:ext><xdr:pic><xdr:nvPicPr><xdr:cNvPr id="2" name="Picture 1"></xdr:cNvPr>`
+	if !strings.Contains(str, expected) {
+		t.Error("one cell anchor should be written.", str)
+	}
+	expected = `<xdr:twoCellAnchor><xdr:from><xdr:col>3</xdr:col><xdr:colOff>0</xdr:colOff><xdr:row>1</xdr:row><xdr:rowOff>0</xdr:rowOff></xdr:from>` +
+		`<xdr:to><xdr:col>5</xdr:col><xdr:colOff>0</xdr:colOff><xdr:row>7</xdr:row><xdr:rowOff>0</xdr:rowOff></xdr:to><xdr:pic><xdr:nvPicPr><xdr:cNvPr id="3" name="signature">`
+	if !strings.Contains(str, expected) {
+		t.Error("two cell anchor should be written.", str)
+	}
+	if !strings.Contains(str, `<a:blip xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" r:embed="rId2">`) {
+		t.Error("blip should refer to the image.", str)
+	}
+	str = readPart(workbook, "xl/drawings/_rels/drawing1.xml.rels")
+	if !strings.Contains(str, `Target="../media/image1.png"`) || !strings.Contains(str, `Target="../media/image1.jpeg"`) {
+		t.Error("image relationships should be written.", str)
+	}
+	if str = readPart(workbook, "xl/worksheets/sheet1.xml"); !strings.Contains(str, `</sheetData><drawing r:id="rId1"></drawing>`) {
+		t.Error("drawing should be written.", str)
+	}
+	if readPart(workbook, "xl/media/image1.png") != pngData.String() {
+		t.Error("image should be stored.")
+	}
+	if !workbook.types.hasOverride("/xl/drawings/drawing1.xml") {
+		t.Error("content type of drawing should be added.")
+	}
+	exts := ""
+	for _, d := range workbook.types.types.Defaults {
+		exts += d.Extension + ","
+	}
+	if exts != "rels,png,jpeg," {
+		t.Error("content types of images should be added.", exts)
+	}
+
+	// 既存の描画パーツに追加する
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	sheet.AddImageReader(bytes.NewReader(pngData.Bytes()), ImageAnchor{From: "A10"})
+	sheet.Close()
+	str = readPart(workbook, "xl/drawings/drawing1.xml")
+	if !strings.HasPrefix(str[strings.Index(str, "<xdr:wsDr"):], `<xdr:wsDr xmlns:xdr=`) || strings.Count(str, "<xdr:pic>") != 3 || !strings.Contains(str, `<xdr:cNvPr id="4" name="Picture 3">`) {
+		t.Error("image should be added to the existing drawing.", str)
+	}
+	if !strings.Contains(readPart(workbook, "xl/drawings/_rels/drawing1.xml.rels"), `Target="../media/image2.png"`) {
+		t.Error("new image should be stored with a new name.")
+	}
+	if str = readPart(workbook, "xl/worksheets/sheet1.xml"); strings.Count(str, "<drawing ") != 1 {
+		t.Error("drawing should not be duplicated.", str)
+	}
+}
@@ -0,0 +1,132 @@
+package excl
+
+import (
+	"encoding/xml"
+	"errors"
+	"strconv"
+)
+
+// getMergedRanges mergeCellsタグから結合範囲を取得する
+func getMergedRanges(tag *Tag) []cellRange {
+	var ranges []cellRange
+	for _, child := range tag.Children {
+		if c, ok := child.(*Tag); ok && c.Name.Local == "mergeCell" {
+			ref, _ := c.getAttr("ref")
+			if r, err := parseRange(ref); err == nil {
+				ranges = append(ranges, r)
+			}
+		}
+	}
+	return ranges
+}
+
+// MergeCells merge cells in the range such as "A1:D1"
+// The value of the top-left cell is kept and values of other cells are cleared.
+// Values set to the other cells after merging are ignored.
+// Ranges which include rows already output cannot be merged.
+func (sheet *Sheet) MergeCells(ref string) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	r, err := parseRange(ref)
+	if err != nil {
+		return err
+	}
+	if r.minRow == r.maxRow && r.minCol == r.maxCol {
+		return errors.New("The range [" + ref + "] should contain more than one cell.")
+	}
+	if r.minRow <= sheet.outputRowNo {
+		return errors.New("The range [" + ref + "] includes rows already output.")
+	}
+	for _, merged := range sheet.mergedRanges {
+		if r.overlaps(merged) {
+			return errors.New("The range [" + ref + "] overlaps the merged range [" + merged.String() + "].")
+		}
+	}
+	sheet.mergedRanges = append(sheet.mergedRanges, r)
+	sheet.setMergeCellsTag()
+	for _, row := range sheet.Rows {
+		if row != nil && r.minRow <= row.rowID && row.rowID <= r.maxRow {
+			sheet.clearMergedCells(row)
+		}
+	}
+	return nil
+}
+
+// UnmergeCells unmerge the merged range
+func (sheet *Sheet) UnmergeCells(ref string) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	r, err := parseRange(ref)
+	if err != nil {
+		return err
+	}
+	for i, merged := range sheet.mergedRanges {
+		if merged == r {
+			sheet.mergedRanges = append(sheet.mergedRanges[:i], sheet.mergedRanges[i+1:]...)
+			sheet.setMergeCellsTag()
+			return nil
+		}
+	}
+	return errors.New("The range [" + ref + "] is not merged.")
+}
+
+// MergedRanges get the merged ranges such as "A1:D1"
+func (sheet *Sheet) MergedRanges() []string {
+	ranges := make([]string, len(sheet.mergedRanges))
+	for i, r := range sheet.mergedRanges {
+		ranges[i] = r.String()
+	}
+	return ranges
+}
+
+// setMergeCellsTag 結合範囲をworksheetのmergeCellsタグに反映する
+func (sheet *Sheet) setMergeCellsTag() {
+	if len(sheet.mergedRanges) == 0 {
+		sheet.worksheet.removeChild("mergeCells")
+		return
+	}
+	tag := &Tag{Name: xml.Name{Local: "mergeCells"}}
+	tag.setAttr("count", strconv.Itoa(len(sheet.mergedRanges)))
+	for _, r := range sheet.mergedRanges {
+		cell := &Tag{Name: xml.Name{Local: "mergeCell"}}
+		cell.setAttr("ref", r.String())
+		tag.Children = append(tag.Children, cell)
+	}
+	sheet.worksheet.insertChild(tag, worksheetOrder)
+}
+
+// clearMergedCells 結合範囲の左上以外のセルの値を削除する
+// 書式は結合範囲の罫線などに使われるため残す
+func (sheet *Sheet) clearMergedCells(row *Row) {
+	for _, r := range sheet.mergedRanges {
+		if row.rowID < r.minRow || r.maxRow < row.rowID {
+			continue
+		}
+		for _, cell := range row.cells {
+			if cell == nil || !r.contains(row.rowID, cell.colNo) {
+				continue
+			}
+			if row.rowID == r.minRow && cell.colNo == r.minCol {
+				continue
+			}
+			cell.cell.Children = nil
+			cell.cell.deleteAttr("t")
+		}
+	}
+}
+
+// mergedAway 結合範囲の左上以外のセルか確認する
+func (cell *Cell) mergedAway() bool {
+	if cell.row == nil || cell.row.sheet == nil {
+		return false
+	}
+	rowID := cell.row.rowID
+	for _, r := range cell.row.sheet.mergedRanges {
+		if r.contains(rowID, cell.colNo) {
+			return rowID != r.minRow || cell.colNo != r.minCol
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package excl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestMergeCells(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	sheet.GetRow(1).SetString("title", 1)
+	sheet.GetRow(1).SetString("hidden", 2)
+	if err := sheet.MergeCells("A1:D1"); err != nil {
+		t.Fatal("cells should be merged.", err.Error())
+	}
+	if sheet.GetRow(1).GetCell(2).Type() != CellTypeBlank {
+		t.Error("value of non-anchor cell should be cleared.")
+	}
+	if v, _ := sheet.GetRow(1).GetCell(1).GetString(); v != "title" {
+		t.Error("value of anchor cell should be kept but", v)
+	}
+	if err := sheet.MergeCells("C1:C3"); err == nil {
+		t.Error("overlapped range should not be merged.")
+	}
+	if err := sheet.MergeCells("A2"); err == nil {
+		t.Error("one cell should not be merged.")
+	}
+	if err := sheet.MergeCells("B3:A2"); err != nil {
+		t.Error("cells should be merged.", err.Error())
+	}
+	ranges := sheet.MergedRanges()
+	if len(ranges) != 2 || ranges[0] != "A1:D1" || ranges[1] != "A2:B3" {
+		t.Error("merged ranges are invalid.", ranges)
+	}
+	if err := sheet.UnmergeCells("A2:B2"); err == nil {
+		t.Error("range which is not merged should not be unmerged.")
+	}
+	if err := sheet.UnmergeCells("A2:B3"); err != nil {
+		t.Error("range should be unmerged.", err.Error())
+	}
+	sheet.OutputThroughRowNo(1)
+	if err := sheet.MergeCells("A1:B2"); err == nil {
+		t.Error("range including output rows should not be merged.")
+	}
+	if err := sheet.MergeCells("A5:C5"); err != nil {
+		t.Error("cells should be merged.", err.Error())
+	}
+	sheet.GetRow(5).SetString("written after merge", 2)
+	sheet.GetRow(5).SetNumber(1, 3)
+	if v, _ := sheet.GetRow(5).GetCell(2).GetString(); v != "" {
+		t.Error("value written to non-anchor cell should be ignored but", v)
+	}
+	if sheet.GetRow(5).GetCell(3).Type() != CellTypeBlank {
+		t.Error("number written to non-anchor cell should be ignored.")
+	}
+	sheet.GetRow(5).SetString("anchor", 1)
+	if v, _ := sheet.GetRow(5).GetCell(1).GetString(); v != "anchor" {
+		t.Error("value of anchor cell should be set but", v)
+	}
+	sheet.Close()
+
+	f, _ := workbook.storage.Open("xl/worksheets/sheet1.xml")
+	b, _ := ioutil.ReadAll(f)
+	f.Close()
+	str := string(b)
+	if !strings.Contains(str, `</sheetData><mergeCells count="2"><mergeCell ref="A1:D1"></mergeCell><mergeCell ref="A5:C5"></mergeCell></mergeCells>`) {
+		t.Error("mergeCells should be written after sheetData.", str)
+	}
+	if strings.Contains(str, `r="B5" t="s"`) {
+		t.Error("value of non-anchor cell should not be written.", str)
+	}
+
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	if ranges = sheet.MergedRanges(); len(ranges) != 2 {
+		t.Error("merged ranges should be read.", ranges)
+	}
+	sheet.UnmergeCells("A1:D1")
+	sheet.UnmergeCells("A5:C5")
+	sheet.Close()
+	var buf bytes.Buffer
+	f, _ = workbook.storage.Open("xl/worksheets/sheet1.xml")
+	buf.ReadFrom(f)
+	f.Close()
+	if strings.Contains(buf.String(), "mergeCells") {
+		t.Error("mergeCells should be removed.")
+	}
+}
@@ -0,0 +1,327 @@
+package excl
+
+import (
+	"encoding/xml"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// plainSheetNamePattern 参照でクォートが不要なシート名
+var plainSheetNamePattern = regexp.MustCompile(`^[\p{L}_][\p{L}0-9_.]*$`)
+
+// cellLikeNamePattern "AB12"や"R1C1"のようにセルの参照と紛らわしい名前
+var cellLikeNamePattern = regexp.MustCompile(`^([A-Z]{1,3}[0-9]+|R[0-9]*C?[0-9]*)$`)
+
+// namePattern 名前やテーブル名に使える文字列
+var namePattern = regexp.MustCompile(`^[\p{L}_\\][\p{L}0-9_.\\]*$`)
+
+// isValidName 名前として使えるか確認する
+func isValidName(name string) bool {
+	if len(name) > 255 || !namePattern.MatchString(name) {
+		return false
+	}
+	upper := strings.ToUpper(name)
+	return upper != "C" && !cellLikeNamePattern.MatchString(upper)
+}
+
+// quoteSheetName 参照に使うシート名を必要に応じてクォートする
+func quoteSheetName(name string) string {
+	if plainSheetNamePattern.MatchString(name) && !cellLikeNamePattern.MatchString(strings.ToUpper(name)) {
+		return name
+	}
+	return "'" + strings.Replace(name, "'", "''", -1) + "'"
+}
+
+// absoluteRef "Sheet1!$A$1:$D$3"のようなシート名付きの絶対参照を取得する
+func absoluteRef(sheetName string, r cellRange) string {
+	ref := "$" + ColStringPosition(r.minCol) + "$" + strconv.Itoa(r.minRow)
+	if r.minRow != r.maxRow || r.minCol != r.maxCol {
+		ref += ":$" + ColStringPosition(r.maxCol) + "$" + strconv.Itoa(r.maxRow)
+	}
+	return quoteSheetName(sheetName) + "!" + ref
+}
+
+// sheetIndex シートのlocalSheetIdを取得する。見つからない場合は-1
+func (workbook *Workbook) sheetIndex(sheet *Sheet) int {
+	for i, s := range workbook.sheets {
+		if s == sheet {
+			return i
+		}
+	}
+	return -1
+}
+
+// findDefinedName 名前とlocalSheetIdが一致するdefinedNameタグを取得する
+// localSheetIDが-1の場合はブック全体の名前を探す
+func (workbook *Workbook) findDefinedName(name string, localSheetID int) *Tag {
+	names := workbook.workbookTag.getChild("definedNames")
+	if names == nil {
+		return nil
+	}
+	for _, child := range names.Children {
+		tag, ok := child.(*Tag)
+		if !ok || tag.Name.Local != "definedName" {
+			continue
+		}
+		if n, _ := tag.getAttr("name"); !strings.EqualFold(n, name) {
+			continue
+		}
+		id := -1
+		if str, err := tag.getAttr("localSheetId"); err == nil {
+			if id, err = strconv.Atoi(str); err != nil || id < 0 {
+				continue
+			}
+		}
+		if id == localSheetID {
+			return tag
+		}
+	}
+	return nil
+}
+
+// setDefinedName 名前を定義する。同じ名前がある場合は値を置き換える
+func (workbook *Workbook) setDefinedName(name string, localSheetID int, value string, hidden bool) {
+	tag := workbook.findDefinedName(name, localSheetID)
+	if tag == nil {
+		names := workbook.workbookTag.getChild("definedNames")
+		if names == nil {
+			names = &Tag{Name: xml.Name{Local: "definedNames"}}
+			workbook.workbookTag.insertChild(names, workbookOrder)
+		}
+		tag = &Tag{Name: xml.Name{Local: "definedName"}}
+		tag.setAttr("name", name)
+		if localSheetID >= 0 {
+			tag.setAttr("localSheetId", strconv.Itoa(localSheetID))
+		}
+		names.Children = append(names.Children, tag)
+	}
+	if hidden {
+		tag.setAttr("hidden", "1")
+	} else {
+		tag.deleteAttr("hidden")
+	}
+	tag.Children = []interface{}{xml.CharData(value)}
+}
+
+// deleteDefinedName 定義された名前を削除する。削除した場合はtrueを返す
+func (workbook *Workbook) deleteDefinedName(name string, localSheetID int) bool {
+	tag := workbook.findDefinedName(name, localSheetID)
+	if tag == nil {
+		return false
+	}
+	names := workbook.workbookTag.getChild("definedNames")
+	var children []interface{}
+	for _, child := range names.Children {
+		if child != tag {
+			children = append(children, child)
+		}
+	}
+	names.Children = children
+	if len(names.Children) == 0 {
+		workbook.workbookTag.removeChild("definedNames")
+	}
+	return true
+}
+
+// DefinedName 定義された名前
+type DefinedName struct {
+	// Name 名前
+	Name string
+	// RefersTo 参照先("Sheet1!$A$1:$B$3"など)
+	RefersTo string
+	// Scope 名前を使えるシート名。空の場合はブック全体
+	Scope string
+	// Hidden 非表示の名前
+	Hidden bool
+}
+
+// DefineName define the name which refers to the range such as "Sheet1!$A$1:$B$3"
+// The scope is the name of the sheet for the sheet-local name, or empty for the workbook.
+// The name which already exists in the same scope is replaced.
+func (workbook *Workbook) DefineName(name string, refersTo string, scope string) error {
+	if !isValidName(name) {
+		return errors.New("The name [" + name + "] is invalid.")
+	}
+	refersTo = strings.TrimPrefix(refersTo, "=")
+	if refersTo == "" {
+		return errors.New("The reference of the name [" + name + "] is empty.")
+	}
+	id, err := workbook.scopeID(scope)
+	if err != nil {
+		return err
+	}
+	parts, err := readTableParts(workbook.storage)
+	if err != nil {
+		return err
+	}
+	for _, part := range parts {
+		if n, _ := part.tag.getAttr("name"); strings.EqualFold(n, name) {
+			return errors.New("The name [" + name + "] is used by the table.")
+		}
+	}
+	workbook.setDefinedName(name, id, refersTo, false)
+	return nil
+}
+
+// DefinedNames get all defined names
+// Names whose localSheetId does not refer to a sheet are skipped.
+func (workbook *Workbook) DefinedNames() []DefinedName {
+	names := workbook.workbookTag.getChild("definedNames")
+	if names == nil {
+		return nil
+	}
+	var list []DefinedName
+	for _, tag := range tagChildren(names, "definedName") {
+		name := DefinedName{RefersTo: tag.text()}
+		name.Name, _ = tag.getAttr("name")
+		if str, err := tag.getAttr("localSheetId"); err == nil {
+			id, err := strconv.Atoi(str)
+			if err != nil || id < 0 || id >= len(workbook.sheets) {
+				continue
+			}
+			name.Scope = workbook.sheets[id].xml.Name
+		}
+		if hidden, _ := tag.getAttr("hidden"); hidden == "1" || hidden == "true" {
+			name.Hidden = true
+		}
+		list = append(list, name)
+	}
+	return list
+}
+
+// DeleteName delete the name in the scope
+func (workbook *Workbook) DeleteName(name string, scope string) error {
+	id, err := workbook.scopeID(scope)
+	if err != nil {
+		return err
+	}
+	if !workbook.deleteDefinedName(name, id) {
+		return errors.New("The name [" + name + "] is not defined.")
+	}
+	return nil
+}
+
+// ResolveName get the sheet name and the range such as "A1:B3" which the name refers to
+// Whole rows and columns are returned as "1:2" and "A:B", and print titles as "A:B,1:2".
+// If the scope is not empty, the sheet-local name is used before the workbook name.
+func (workbook *Workbook) ResolveName(name string, scope string) (string, string, error) {
+	id, err := workbook.scopeID(scope)
+	if err != nil {
+		return "", "", err
+	}
+	tag := workbook.findDefinedName(name, id)
+	if tag == nil && id >= 0 {
+		tag = workbook.findDefinedName(name, -1)
+	}
+	if tag == nil {
+		return "", "", errors.New("The name [" + name + "] is not defined.")
+	}
+	return parseNameRef(tag.text())
+}
+
+// scopeID シート名からlocalSheetIdを取得する。空の場合は-1
+func (workbook *Workbook) scopeID(scope string) (int, error) {
+	if scope == "" {
+		return -1, nil
+	}
+	sheet := workbook.getSheet(scope)
+	if sheet == nil {
+		return -1, errors.New("The sheet [" + scope + "] does not exist.")
+	}
+	return workbook.sheetIndex(sheet), nil
+}
+
+// parseNameRef "'My Sheet'!$A$1:$B$3"のような参照をシート名と範囲に分ける
+// 行全体("$1:$2")と列全体("$A:$B")も扱い、印刷タイトルのような列全体と行全体の組み合わせは"A:B,1:2"にする
+func parseNameRef(ref string) (string, string, error) {
+	areas := splitNameRef(strings.TrimPrefix(ref, "="))
+	if len(areas) > 2 {
+		return "", "", errors.New("The reference [" + ref + "] is not a range.")
+	}
+	var sheetName string
+	var ranges []string
+	lines := 0
+	for i, area := range areas {
+		name, str, err := splitSheetRef(area)
+		if err != nil {
+			return "", "", errors.New("The reference [" + ref + "] " + err.Error())
+		}
+		if i > 0 && name != sheetName {
+			return "", "", errors.New("The reference [" + ref + "] is not a range.")
+		}
+		sheetName = name
+		if r, err := parseRange(str); err == nil {
+			ranges = append(ranges, r.String())
+		} else if strs := titleColsPattern.FindStringSubmatch(strings.ToUpper(str)); strs != nil && ColNumPosition(strs[1]) <= ColNumPosition(strs[2]) {
+			ranges = append(ranges, strs[1]+":"+strs[2])
+			lines |= 1
+		} else if strs := titleRowsPattern.FindStringSubmatch(str); strs != nil && lineRangeValid(strs[1], strs[2]) {
+			ranges = append(ranges, strs[1]+":"+strs[2])
+			lines |= 2
+		} else {
+			return "", "", errors.New("The reference [" + ref + "] is not a range.")
+		}
+	}
+	// 複数の範囲は列全体と行全体の組み合わせのみ扱う
+	if len(ranges) == 2 && lines != 3 {
+		return "", "", errors.New("The reference [" + ref + "] is not a range.")
+	}
+	return sheetName, strings.Join(ranges, ","), nil
+}
+
+// splitNameRef 参照を引用符の外のカンマで分ける
+func splitNameRef(ref string) []string {
+	var areas []string
+	start := 0
+	inQuote := false
+	for i := 0; i < len(ref); i++ {
+		switch ref[i] {
+		case '\'':
+			inQuote = !inQuote
+		case ',':
+			if !inQuote {
+				areas = append(areas, ref[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(areas, ref[start:])
+}
+
+// splitSheetRef "'My Sheet'!$A$1"のような参照をシート名と範囲の文字列に分ける
+func splitSheetRef(ref string) (string, string, error) {
+	if !strings.HasPrefix(ref, "'") {
+		i := strings.Index(ref, "!")
+		if i <= 0 {
+			return "", "", errors.New("is not a range.")
+		}
+		return ref[:i], ref[i+1:], nil
+	}
+	i := 1
+	for ; i < len(ref); i++ {
+		if ref[i] != '\'' {
+			continue
+		}
+		if i+1 < len(ref) && ref[i+1] == '\'' {
+			i++
+			continue
+		}
+		break
+	}
+	if i >= len(ref) {
+		return "", "", errors.New("is invalid.")
+	}
+	if !strings.HasPrefix(ref[i+1:], "!") {
+		return "", "", errors.New("is not a range.")
+	}
+	return strings.Replace(ref[1:i], "''", "'", -1), ref[i+2:], nil
+}
+
+// lineRangeValid "1:2"のような行の範囲が正しいか確認する
+func lineRangeValid(from string, to string) bool {
+	f, _ := strconv.Atoi(from)
+	t, _ := strconv.Atoi(to)
+	return f > 0 && f <= t
+}
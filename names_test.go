@@ -0,0 +1,157 @@
+package excl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestDefineName(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	workbook.OpenSheet("Sheet1")
+	workbook.OpenSheet("My Sheet")
+	if err := workbook.DefineName("InputArea", "=Sheet1!$B$2:$D$10", ""); err != nil {
+		t.Fatal("name should be defined.", err.Error())
+	}
+	if err := workbook.DefineName("InputArea", "'My Sheet'!$A$1", "my sheet"); err != nil {
+		t.Fatal("sheet-local name should be defined.", err.Error())
+	}
+	if err := workbook.DefineName("Rate", "0.08", ""); err != nil {
+		t.Fatal("constant name should be defined.", err.Error())
+	}
+	if err := workbook.DefineName("A1", "Sheet1!$A$1", ""); err == nil {
+		t.Error("name like a cell should be error.")
+	}
+	if err := workbook.DefineName("Input Area", "Sheet1!$A$1", ""); err == nil {
+		t.Error("name with space should be error.")
+	}
+	if err := workbook.DefineName("Area", "Sheet1!$A$1", "Unknown"); err == nil {
+		t.Error("unknown scope should be error.")
+	}
+	if err := workbook.DefineName("Area", "", ""); err == nil {
+		t.Error("empty reference should be error.")
+	}
+
+	sheetName, ref, err := workbook.ResolveName("inputarea", "")
+	if err != nil || sheetName != "Sheet1" || ref != "B2:D10" {
+		t.Error("name should be resolved to Sheet1 B2:D10.", sheetName, ref, err)
+	}
+	if sheetName, ref, _ = workbook.ResolveName("InputArea", "My Sheet"); sheetName != "My Sheet" || ref != "A1" {
+		t.Error("sheet-local name should be used.", sheetName, ref)
+	}
+	if sheetName, ref, _ = workbook.ResolveName("InputArea", "Sheet1"); sheetName != "Sheet1" || ref != "B2:D10" {
+		t.Error("workbook name should be used when the sheet-local name does not exist.", sheetName, ref)
+	}
+	if _, _, err = workbook.ResolveName("Rate", ""); err == nil {
+		t.Error("constant name should not be resolved to a range.")
+	}
+	if _, _, err = workbook.ResolveName("Unknown", ""); err == nil {
+		t.Error("unknown name should be error.")
+	}
+
+	names := workbook.DefinedNames()
+	if len(names) != 3 || names[1] != (DefinedName{Name: "InputArea", RefersTo: "'My Sheet'!$A$1", Scope: "My Sheet"}) {
+		t.Error("defined names are invalid.", names)
+	}
+	if err = workbook.DeleteName("Rate", ""); err != nil {
+		t.Error("name should be deleted.", err.Error())
+	}
+	if err = workbook.DeleteName("Rate", ""); err == nil {
+		t.Error("deleted name should be error.")
+	}
+
+	var buf bytes.Buffer
+	if _, err = workbook.WriteTo(&buf); err != nil {
+		t.Fatal("workbook should be written.", err.Error())
+	}
+	workbook, err = OpenBytes(buf.Bytes())
+	if err != nil {
+		t.Fatal("workbook should be opened.", err.Error())
+	}
+	defer workbook.Close()
+	str := readPart(workbook, "xl/workbook.xml")
+	expected := `<definedNames><definedName name="InputArea">Sheet1!$B$2:$D$10</definedName>` +
+		`<definedName name="InputArea" localSheetId="1">&#39;My Sheet&#39;!$A$1</definedName></definedNames>`
+	if !strings.Contains(str, expected) {
+		t.Error("defined names should be written.", str)
+	}
+	if sheetName, ref, _ = workbook.ResolveName("InputArea", "My Sheet"); sheetName != "My Sheet" || ref != "A1" {
+		t.Error("name should be resolved in the opened workbook.", sheetName, ref)
+	}
+}
+
+func TestDefinedNamesWithInvalidScope(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	workbook.OpenSheet("Sheet1")
+	workbook.DefineName("Valid", "Sheet1!$A$1", "Sheet1")
+	definedNames := workbook.workbookTag.getChild("definedNames")
+	for _, id := range []string{"x", "1", "-1"} {
+		tag := &Tag{Name: xml.Name{Local: "definedName"}, Children: []interface{}{xml.CharData("Sheet1!$A$1")}}
+		tag.setAttr("name", "Broken")
+		tag.setAttr("localSheetId", id)
+		definedNames.Children = append(definedNames.Children, tag)
+	}
+	names := workbook.DefinedNames()
+	if len(names) != 1 || names[0].Name != "Valid" || names[0].Scope != "Sheet1" {
+		t.Error("names with invalid localSheetId should be skipped.", names)
+	}
+	if _, _, err := workbook.ResolveName("Broken", "Sheet1"); err == nil {
+		t.Error("name with invalid localSheetId should not be resolved.")
+	}
+}
+
+func TestParseNameRef(t *testing.T) {
+	tests := []struct {
+		ref, sheetName, cellRange string
+	}{
+		{"Sheet1!$A$1:$B$3", "Sheet1", "A1:B3"},
+		{"='O''Brien''s'!C5", "O'Brien's", "C5"},
+		{"'a!b'!$D$1:$D$9", "a!b", "D1:D9"},
+		{"Sheet1!$1:$2", "Sheet1", "1:2"},
+		{"Sheet1!$a:$B", "Sheet1", "A:B"},
+		{"'a,b'!$A:$B,'a,b'!$1:$1", "a,b", "A:B,1:1"},
+	}
+	for _, test := range tests {
+		sheetName, r, err := parseNameRef(test.ref)
+		if err != nil || sheetName != test.sheetName || r != test.cellRange {
+			t.Error("reference should be parsed.", test.ref, sheetName, r, err)
+		}
+	}
+	for _, ref := range []string{"Sheet1!$A$1,Sheet1!$B$2", "SUM(Sheet1!A1:A3)", "#REF!", "'Sheet1!A1", "Sheet1!$2:$1", "Sheet1!$0:$1",
+		"Sheet1!$A:$B,Sheet1!$C:$D", "Sheet1!$A:$B,Sheet2!$1:$2", "Sheet1!$A:$B,Sheet1!$1:$2,Sheet1!$3:$4"} {
+		if _, _, err := parseNameRef(ref); err == nil {
+			t.Error("reference should be error.", ref)
+		}
+	}
+}
+
+func TestResolvePrintTitles(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Report 1")
+	sheet.SetPageSetup(PageSetup{PrintTitleRows: "1:2", PrintTitleCols: "A:B"})
+	sheetName, ref, err := workbook.ResolveName(printTitlesName, "Report 1")
+	if err != nil || sheetName != "Report 1" || ref != "A:B,1:2" {
+		t.Error("print titles should be resolved.", sheetName, ref, err)
+	}
+	sheet.SetPageSetup(PageSetup{PrintArea: "A1:H50"})
+	if sheetName, ref, err = workbook.ResolveName(printAreaName, "Report 1"); err != nil || ref != "A1:H50" {
+		t.Error("print area should be resolved.", sheetName, ref, err)
+	}
+	sheet.Close()
+}
+
+func TestIsValidName(t *testing.T) {
+	for _, name := range []string{"Sales", "_total", "\\path", "Tax.Rate", "売上", "Table1"} {
+		if !isValidName(name) {
+			t.Error("name should be valid.", name)
+		}
+	}
+	for _, name := range []string{"", "1st", "A1", "xfd100", "R1C1", "r", "c", "a b", strings.Repeat("a", 256)} {
+		if isValidName(name) {
+			t.Error("name should be invalid.", name)
+		}
+	}
+}
@@ -0,0 +1,640 @@
+package excl
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// builtinNumFmts 組み込みの数値フォーマット
+// 14と22はロケールによって表示が変わるためISO形式にしている
+var builtinNumFmts = map[int]string{
+	0:  "General",
+	1:  "0",
+	2:  "0.00",
+	3:  "#,##0",
+	4:  "#,##0.00",
+	9:  "0%",
+	10: "0.00%",
+	11: "0.00E+00",
+	12: "# ?/?",
+	13: "# ??/??",
+	14: "yyyy-mm-dd",
+	15: "d-mmm-yy",
+	16: "d-mmm",
+	17: "mmm-yy",
+	18: "h:mm AM/PM",
+	19: "h:mm:ss AM/PM",
+	20: "h:mm",
+	21: "h:mm:ss",
+	22: "yyyy-mm-dd h:mm",
+	37: "#,##0 ;(#,##0)",
+	38: "#,##0 ;[Red](#,##0)",
+	39: "#,##0.00;(#,##0.00)",
+	40: "#,##0.00;[Red](#,##0.00)",
+	45: "mm:ss",
+	46: "[h]:mm:ss",
+	47: "mmss.0",
+	48: "##0.0E+0",
+	49: "@",
+}
+
+var monthNames = []string{"January", "February", "March", "April", "May", "June",
+	"July", "August", "September", "October", "November", "December"}
+
+var dayNames = []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"}
+
+// getFormatCode 組み込みを含めて数値フォーマットIDからフォーマット文字列を取得する
+func (styles *Styles) getFormatCode(id int) string {
+	if code, ok := builtinNumFmts[id]; ok {
+		return code
+	}
+	if styles == nil {
+		return ""
+	}
+	return styles.getNumFmtCode(id)
+}
+
+// GetFormattedString get the value of a cell as the text displayed with the number format
+func (cell *Cell) GetFormattedString() (string, error) {
+	switch cell.Type() {
+	case CellTypeNumber, CellTypeDate:
+		f, err := cell.GetNumber()
+		if err != nil {
+			return "", err
+		}
+		code := ""
+		if cell.styles != nil {
+			code = cell.styles.getFormatCode(cell.GetStyle().NumFmtID)
+		}
+		if cell.Type() == CellTypeDate && (code == "" || strings.EqualFold(code, "General")) {
+			code = "yyyy-mm-dd hh:mm:ss"
+		}
+		return formatNumber(f, code), nil
+	case CellTypeError:
+		return cell.rawValue(), nil
+	}
+	return cell.GetString()
+}
+
+// splitSections フォーマットを;で正・負・ゼロ・文字列のセクションに分ける
+func splitSections(code string) []string {
+	var sections []string
+	start := 0
+	inQuote := false
+	for i := 0; i < len(code); i++ {
+		switch c := code[i]; {
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '\\':
+			i++
+		case c == ';':
+			sections = append(sections, code[start:i])
+			start = i + 1
+		}
+	}
+	return append(sections, code[start:])
+}
+
+// fmtToken フォーマットを分解した要素
+type fmtToken struct {
+	kind  byte // 'l' リテラル, 'd' 日付時刻, 'n' 数値
+	value string
+}
+
+// tokenizeFormat セクションをリテラル、日付時刻、数値の要素に分解する
+func tokenizeFormat(section string) []fmtToken {
+	var tokens []fmtToken
+	literal := func(s string) {
+		tokens = append(tokens, fmtToken{kind: 'l', value: s})
+	}
+	for i := 0; i < len(section); i++ {
+		c := section[i]
+		switch {
+		case c == '"':
+			end := strings.IndexByte(section[i+1:], '"')
+			if end < 0 {
+				end = len(section) - i - 1
+			}
+			literal(section[i+1 : i+1+end])
+			i += end + 1
+		case c == '\\':
+			if i+1 < len(section) {
+				literal(section[i+1 : i+2])
+			}
+			i++
+		case c == '_':
+			literal(" ")
+			i++
+		case c == '*':
+			i++
+		case c == '[':
+			end := strings.IndexByte(section[i:], ']')
+			if end < 0 {
+				return tokens
+			}
+			inner := section[i+1 : i+end]
+			lower := strings.ToLower(inner)
+			if lower != "" && strings.Trim(lower, "hms") == "" {
+				tokens = append(tokens, fmtToken{kind: 'd', value: "[" + lower + "]"})
+			} else if strings.HasPrefix(inner, "$") {
+				// [$¥-411] などの通貨記号
+				if dash := strings.IndexByte(inner, '-'); dash >= 0 {
+					literal(inner[1:dash])
+				} else {
+					literal(inner[1:])
+				}
+			}
+			i += end
+		case strings.HasPrefix(strings.ToUpper(section[i:]), "AM/PM"):
+			tokens = append(tokens, fmtToken{kind: 'd', value: "AM/PM"})
+			i += 4
+		case strings.HasPrefix(strings.ToUpper(section[i:]), "A/P"):
+			tokens = append(tokens, fmtToken{kind: 'd', value: "A/P"})
+			i += 2
+		case c == 'E' || c == 'e':
+			if i+1 < len(section) && (section[i+1] == '+' || section[i+1] == '-') {
+				tokens = append(tokens, fmtToken{kind: 'n', value: "E" + section[i+1:i+2]})
+				i++
+			} else if c == 'e' && !strings.ContainsAny(section, "0#?") {
+				// 和暦の年は西暦で表示する
+				tokens = append(tokens, fmtToken{kind: 'd', value: "yyyy"})
+			} else {
+				literal(section[i : i+1])
+			}
+		case strings.IndexByte("yYmMdDhHsS", c) >= 0:
+			j := i
+			for j < len(section) && strings.EqualFold(section[j:j+1], section[i:i+1]) {
+				j++
+			}
+			tokens = append(tokens, fmtToken{kind: 'd', value: strings.ToLower(section[i:j])})
+			i = j - 1
+		case strings.IndexByte("0#?.,%/", c) >= 0:
+			tokens = append(tokens, fmtToken{kind: 'n', value: section[i : i+1]})
+		case c == '@':
+			tokens = append(tokens, fmtToken{kind: 'n', value: "@"})
+		default:
+			literal(section[i : i+1])
+		}
+	}
+	return tokens
+}
+
+// formatNumber 数値フォーマットに従って数値を文字列にする
+func formatNumber(f float64, code string) string {
+	if code == "" || strings.EqualFold(code, "General") {
+		return formatGeneral(f)
+	}
+	sections := splitSections(code)
+	section := sections[0]
+	negative := f < 0
+	switch {
+	case f < 0 && len(sections) >= 2:
+		section = sections[1]
+		f = -f
+		negative = false
+	case f == 0 && len(sections) >= 3:
+		section = sections[2]
+	}
+	if section == "" {
+		// 空のセクションは何も表示しない
+		return ""
+	}
+	tokens := tokenizeFormat(section)
+	isDate := false
+	isNumber := false
+	for _, token := range tokens {
+		if token.kind == 'd' {
+			isDate = true
+		} else if token.kind == 'n' && token.value != "@" {
+			isNumber = true
+		}
+	}
+	if isDate {
+		return formatDateTokens(f, tokens)
+	}
+	if !isNumber {
+		var b strings.Builder
+		for _, token := range tokens {
+			if token.kind == 'l' {
+				b.WriteString(token.value)
+			} else if token.value == "@" {
+				b.WriteString(formatGeneral(f))
+			}
+		}
+		return b.String()
+	}
+	str := formatNumberTokens(math.Abs(f), tokens)
+	if negative && hasNonZeroDigit(str) {
+		str = "-" + str
+	}
+	return str
+}
+
+// hasNonZeroDigit 0以外の数字を含むか確認する
+func hasNonZeroDigit(str string) bool {
+	return strings.ContainsAny(str, "123456789")
+}
+
+// formatGeneral General形式(有効桁数15桁)で数値を文字列にする
+func formatGeneral(f float64) string {
+	if f == 0 {
+		return "0"
+	}
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(f, 'g', 15, 64), 64)
+	abs := math.Abs(rounded)
+	if abs >= 1e11 || abs < 1e-9 {
+		str := strconv.FormatFloat(rounded, 'E', 5, 64)
+		mantissa, exp := str[:strings.IndexByte(str, 'E')], str[strings.IndexByte(str, 'E'):]
+		if strings.Contains(mantissa, ".") {
+			mantissa = strings.TrimRight(strings.TrimRight(mantissa, "0"), ".")
+		}
+		return mantissa + exp
+	}
+	return strconv.FormatFloat(rounded, 'f', -1, 64)
+}
+
+// formatNumberTokens 数値の要素に従って数値を文字列にする
+func formatNumberTokens(f float64, tokens []fmtToken) string {
+	first, last := -1, -1
+	point, exp := -1, -1
+	intDigits, decDigits, expDigits := "", "", ""
+	grouping := false
+	for i, token := range tokens {
+		if token.kind != 'n' {
+			continue
+		}
+		switch v := token.value; {
+		case v == "%":
+			f *= 100
+		case v == "/":
+			return formatGeneral(f)
+		case strings.HasPrefix(v, "E"):
+			exp = i
+		case v == ".":
+			if point < 0 && exp < 0 {
+				point = i
+			}
+		case v == ",":
+		case v == "0" || v == "#" || v == "?":
+			if first < 0 {
+				first = i
+			}
+			last = i
+			switch {
+			case exp >= 0:
+				expDigits += v
+			case point >= 0:
+				decDigits += v
+			default:
+				intDigits += v
+			}
+		}
+	}
+	// 桁区切りと末尾のカンマによる1000単位の表示
+	for i := first; i >= 0 && i <= last; i++ {
+		if tokens[i].kind == 'n' && tokens[i].value == "," && (point < 0 || i < point) && (exp < 0 || i < exp) {
+			if i+1 <= last && isDigitToken(tokens[i+1]) {
+				grouping = true
+			}
+		}
+	}
+	end := last
+	if point >= 0 && point < end {
+		end = point
+	}
+	for i := end + 1; i < len(tokens) && tokens[i].kind == 'n' && tokens[i].value == ","; i++ {
+		f /= 1000
+	}
+
+	var b strings.Builder
+	if exp >= 0 {
+		number := formatScientific(f, intDigits, decDigits, expDigits, tokens[exp].value)
+		for i, token := range tokens {
+			switch {
+			case i == first:
+				b.WriteString(number)
+			case token.kind == 'l' && (i < first || i > last):
+				b.WriteString(token.value)
+			case token.kind == 'n' && token.value == "%":
+				b.WriteString("%")
+			}
+		}
+		return b.String()
+	}
+
+	// 桁の間のリテラル("000-0000"など)は桁の位置に合わせて出力する
+	intPart, decPart := fixedParts(f, intDigits, decDigits, grouping)
+	ints := placeIntDigits(intPart, len(intDigits))
+	intIndex, decIndex := 0, 0
+	for i, token := range tokens {
+		switch {
+		case token.kind == 'l':
+			b.WriteString(token.value)
+		case isDigitToken(token) && (point < 0 || i < point):
+			b.WriteString(ints[intIndex])
+			intIndex++
+		case isDigitToken(token):
+			if decIndex < len(decPart) {
+				b.WriteByte(decPart[decIndex])
+			}
+			decIndex++
+		case token.kind == 'n' && token.value == "%":
+			b.WriteString("%")
+		case token.kind == 'n' && token.value == "." && i == point:
+			if intDigits == "" && first >= 0 {
+				b.WriteString(intPart)
+			}
+			if decDigits != "" || first < 0 {
+				b.WriteString(".")
+			}
+		case token.kind == 'n' && token.value == "." && first < 0:
+			b.WriteString(".")
+		}
+	}
+	return b.String()
+}
+
+// placeIntDigits 整数部の数字を右から桁の要素に割り当てる
+// 桁の要素より数字が多い場合は最初の要素にまとめ、桁区切りのカンマは左の数字と一緒にする
+func placeIntDigits(intPart string, n int) []string {
+	placed := make([]string, n)
+	end := len(intPart)
+	for j := n - 1; j > 0 && end > 0; j-- {
+		start := end - 1
+		if intPart[start] == ',' && start > 0 {
+			start--
+		}
+		placed[j] = intPart[start:end]
+		end = start
+	}
+	if n > 0 {
+		placed[0] = intPart[:end]
+	}
+	return placed
+}
+
+// isDigitToken 桁の要素か確認する
+func isDigitToken(token fmtToken) bool {
+	return token.kind == 'n' && (token.value == "0" || token.value == "#" || token.value == "?")
+}
+
+// formatFixed 固定小数点で数値を文字列にする
+func formatFixed(f float64, intDigits string, decDigits string, grouping bool) string {
+	intPart, decPart := fixedParts(f, intDigits, decDigits, grouping)
+	if decDigits == "" {
+		return intPart
+	}
+	return intPart + "." + decPart
+}
+
+// fixedParts 固定小数点で数値を整数部と小数部の文字列にする
+func fixedParts(f float64, intDigits string, decDigits string, grouping bool) (string, string) {
+	str := roundHalfUp(f, len(decDigits))
+	intPart, decPart := str, ""
+	if dot := strings.IndexByte(str, '.'); dot >= 0 {
+		intPart, decPart = str[:dot], str[dot+1:]
+	}
+	minInt := strings.Count(intDigits, "0")
+	if intPart == "0" && minInt == 0 {
+		intPart = ""
+	}
+	for len(intPart) < minInt {
+		intPart = "0" + intPart
+	}
+	if grouping {
+		intPart = groupThousands(intPart)
+	}
+	return intPart, trimDecimal(decPart, decDigits)
+}
+
+// roundHalfUp 正の数値を小数点以下n桁に四捨五入した文字列にする
+// FormatFloatは偶数丸めになるため、最短表現の10進数文字列を四捨五入する
+func roundHalfUp(f float64, n int) string {
+	str := strconv.FormatFloat(f, 'f', -1, 64)
+	dot := strings.IndexByte(str, '.')
+	if dot < 0 {
+		str += "."
+		dot = len(str) - 1
+	}
+	for len(str)-dot-1 < n+1 {
+		str += "0"
+	}
+	digits := []byte(str[:dot] + str[dot+1:dot+1+n])
+	if str[dot+1+n] >= '5' {
+		i := len(digits) - 1
+		for ; i >= 0; i-- {
+			if digits[i] < '9' {
+				digits[i]++
+				break
+			}
+			digits[i] = '0'
+		}
+		if i < 0 {
+			digits = append([]byte{'1'}, digits...)
+			dot++
+		}
+	}
+	if n == 0 {
+		return string(digits)
+	}
+	return string(digits[:dot]) + "." + string(digits[dot:])
+}
+
+// trimDecimal 小数部の#を末尾の0を除き、?を空白にする
+func trimDecimal(decPart string, decDigits string) string {
+	b := []byte(decPart)
+	for i := len(b) - 1; i >= 0 && b[i] == '0'; i-- {
+		switch decDigits[i] {
+		case '#':
+			b = b[:i]
+		case '?':
+			b[i] = ' '
+		default:
+			return string(b)
+		}
+	}
+	return string(b)
+}
+
+// groupThousands 3桁ごとにカンマを入れる
+func groupThousands(str string) string {
+	if len(str) <= 3 {
+		return str
+	}
+	var b strings.Builder
+	head := len(str) % 3
+	if head > 0 {
+		b.WriteString(str[:head])
+	}
+	for i := head; i < len(str); i += 3 {
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(str[i : i+3])
+	}
+	return b.String()
+}
+
+// formatScientific 指数表記で数値を文字列にする
+func formatScientific(f float64, intDigits string, decDigits string, expDigits string, sign string) string {
+	exponent := 0
+	if f != 0 {
+		exponent = int(math.Floor(math.Log10(f)))
+		// ##0.0E+0 のように整数部が複数桁の場合は指数を桁数の倍数にする
+		if n := len(intDigits); n > 1 {
+			exponent = int(math.Floor(float64(exponent)/float64(n))) * n
+		}
+	}
+	mantissa := f / math.Pow(10, float64(exponent))
+	str := formatFixed(mantissa, intDigits, decDigits, false)
+	if strings.HasPrefix(str, "10") && len(intDigits) <= 1 && f != 0 {
+		// 丸めによって仮数部が10になった場合
+		exponent++
+		str = formatFixed(f/math.Pow(10, float64(exponent)), intDigits, decDigits, false)
+	}
+	expStr := strconv.Itoa(int(math.Abs(float64(exponent))))
+	for len(expStr) < strings.Count(expDigits, "0") {
+		expStr = "0" + expStr
+	}
+	if exponent < 0 {
+		return str + "E-" + expStr
+	}
+	if sign == "E+" {
+		return str + "E+" + expStr
+	}
+	return str + "E" + expStr
+}
+
+// formatDateTokens 日付時刻の要素に従ってシリアル値を文字列にする
+func formatDateTokens(f float64, tokens []fmtToken) string {
+	subSecond := 0
+	hasAmPm := false
+	for i, token := range tokens {
+		if token.kind == 'd' && (token.value == "AM/PM" || token.value == "A/P") {
+			hasAmPm = true
+		}
+		// ss.00 の小数秒
+		if token.kind == 'n' && token.value == "." && i > 0 && tokens[i-1].kind == 'd' && tokens[i-1].value[0] == 's' {
+			for j := i + 1; j < len(tokens) && tokens[j].kind == 'n' && tokens[j].value == "0"; j++ {
+				subSecond++
+			}
+		}
+	}
+	unit := time.Second
+	for i := 0; i < subSecond; i++ {
+		unit /= 10
+	}
+	t := excelTimeToTime(f).Round(unit)
+	elapsed := time.Duration(math.Round(f*24*60*60/unit.Seconds())) * unit
+
+	var b strings.Builder
+	for i := 0; i < len(tokens); i++ {
+		token := tokens[i]
+		if token.kind == 'l' {
+			b.WriteString(token.value)
+			continue
+		}
+		if token.kind == 'n' {
+			if token.value == "." && subSecond > 0 {
+				frac := strconv.FormatInt(int64(t.Nanosecond())/int64(unit), 10)
+				for len(frac) < subSecond {
+					frac = "0" + frac
+				}
+				b.WriteString("." + frac)
+				i += subSecond
+			} else {
+				b.WriteString(token.value)
+			}
+			continue
+		}
+		switch v := token.value; v {
+		case "yy":
+			b.WriteString(t.Format("06"))
+		case "y", "yyy", "yyyy":
+			b.WriteString(strconv.Itoa(t.Year()))
+		case "m", "mm":
+			if isMinuteToken(tokens, i) {
+				b.WriteString(padNumber(t.Minute(), len(v)))
+			} else {
+				b.WriteString(padNumber(int(t.Month()), len(v)))
+			}
+		case "mmm":
+			b.WriteString(monthNames[t.Month()-1][:3])
+		case "mmmmm":
+			b.WriteString(monthNames[t.Month()-1][:1])
+		case "d", "dd":
+			b.WriteString(padNumber(t.Day(), len(v)))
+		case "ddd":
+			b.WriteString(dayNames[t.Weekday()][:3])
+		case "h", "hh":
+			hour := t.Hour()
+			if hasAmPm {
+				hour = (hour+11)%12 + 1
+			}
+			b.WriteString(padNumber(hour, len(v)))
+		case "s", "ss":
+			b.WriteString(padNumber(t.Second(), len(v)))
+		case "AM/PM":
+			if t.Hour() < 12 {
+				b.WriteString("AM")
+			} else {
+				b.WriteString("PM")
+			}
+		case "A/P":
+			if t.Hour() < 12 {
+				b.WriteString("A")
+			} else {
+				b.WriteString("P")
+			}
+		case "[h]", "[hh]":
+			b.WriteString(padNumber(int(elapsed/time.Hour), len(v)-2))
+		case "[m]", "[mm]":
+			b.WriteString(padNumber(int(elapsed/time.Minute), len(v)-2))
+		case "[s]", "[ss]":
+			b.WriteString(padNumber(int(elapsed/time.Second), len(v)-2))
+		default:
+			if strings.HasPrefix(v, "mmmm") {
+				b.WriteString(monthNames[t.Month()-1])
+			} else if strings.HasPrefix(v, "dddd") {
+				b.WriteString(dayNames[t.Weekday()])
+			} else if v[0] == 'y' {
+				b.WriteString(strconv.Itoa(t.Year()))
+			} else {
+				b.WriteString(v)
+			}
+		}
+	}
+	return b.String()
+}
+
+// isMinuteToken mが分を表すか確認する(時の後または秒の前のm)
+func isMinuteToken(tokens []fmtToken, index int) bool {
+	for i := index - 1; i >= 0; i-- {
+		if tokens[i].kind != 'd' {
+			continue
+		}
+		if v := tokens[i].value; v[0] == 'h' || strings.HasPrefix(v, "[h") {
+			return true
+		}
+		break
+	}
+	for i := index + 1; i < len(tokens); i++ {
+		if tokens[i].kind != 'd' {
+			continue
+		}
+		return tokens[i].value[0] == 's' || strings.HasPrefix(tokens[i].value, "[s")
+	}
+	return false
+}
+
+// padNumber 指定した桁数まで0で埋める
+func padNumber(n int, width int) string {
+	str := strconv.Itoa(n)
+	for len(str) < width {
+		str = "0" + str
+	}
+	return str
+}
@@ -0,0 +1,59 @@
+package excl
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatNumber(t *testing.T) {
+	date := timeToExcelTime(time.Date(2017, 4, 1, 13, 5, 9, 0, time.UTC))
+	tests := []struct {
+		value    float64
+		code     string
+		expected string
+	}{
+		{1234.5, "General", "1234.5"},
+		{0.1 + 0.2, "", "0.3"},
+		{123456789012, "General", "1.23457E+11"},
+		{-5, "General", "-5"},
+		{1234.5, "0", "1235"},
+		{1234.5, "0.00", "1234.50"},
+		{1234567.891, "#,##0.00", "1,234,567.89"},
+		{-1234.5, "#,##0", "-1,235"},
+		{-1234.5, "#,##0;(#,##0)", "(1,235)"},
+		{0, "#,##0;(#,##0);\"-\"", "-"},
+		{0.256, "0.0%", "25.6%"},
+		{12345, "0.00E+00", "1.23E+04"},
+		{0.5, "#.##", ".5"},
+		{1.5, "0.0#", "1.5"},
+		{1234567, "#,##0,\"K\"", "1,235K"},
+		{1234.5, "[$¥-411]#,##0", "¥1,235"},
+		{1234.5, "\"$\"#,##0.00_);[Red]\\(\"$\"#,##0.00\\)", "$1,234.50 "},
+		{-0.001, "0.00", "0.00"},
+		{9.995, "0.00", "10.00"},
+		{0.5, "0", "1"},
+		{date, "yyyy-mm-dd", "2017-04-01"},
+		{date, "yyyy/m/d h:mm:ss", "2017/4/1 13:05:09"},
+		{date, "mmm d, yyyy", "Apr 1, 2017"},
+		{date, "dddd mmmm", "Saturday April"},
+		{date, "h:mm AM/PM", "1:05 PM"},
+		{1.5, "[h]:mm", "36:00"},
+		{date, "yyyy\"年\"m\"月\"d\"日\"", "2017年4月1日"},
+		{date, "hh:mm:ss.00", "13:05:09.00"},
+		{42, "@", "42"},
+		{1234567, "000-0000", "123-4567"},
+		{1234567, "000\"-\"0000", "123-4567"},
+		{9012345678, "000-0000-0000", "090-1234-5678"},
+		{5, "#-00", "-05"},
+		{1234.5, "0\"/\"0.0\"h\"", "123/4.5h"},
+		{-5, "0;", ""},
+		{0, "0;-0;", ""},
+		{5, "0;", "5"},
+		{-5, "0;;", ""},
+	}
+	for _, test := range tests {
+		if str := formatNumber(test.value, test.code); str != test.expected {
+			t.Error("format", test.code, "of", test.value, "should be", test.expected, "but", str)
+		}
+	}
+}
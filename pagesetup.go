@@ -0,0 +1,357 @@
+package excl
+
+import (
+	"encoding/xml"
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// printAreaName 印刷範囲を表す名前
+	printAreaName = "_xlnm.Print_Area"
+	// printTitlesName 印刷タイトルを表す名前
+	printTitlesName = "_xlnm.Print_Titles"
+)
+
+// sheetPrOrder sheetPrの子タグの順序
+var sheetPrOrder = []string{"tabColor", "outlinePr", "pageSetUpPr"}
+
+var (
+	titleRowsPattern = regexp.MustCompile(`^\$?([0-9]+):\$?([0-9]+)$`)
+	titleColsPattern = regexp.MustCompile(`^\$?([A-Za-z]+):\$?([A-Za-z]+)$`)
+)
+
+// PageOrientation 用紙の向き
+type PageOrientation string
+
+const (
+	// OrientationDefault 既定の向き(縦)
+	OrientationDefault PageOrientation = ""
+	// OrientationPortrait 縦
+	OrientationPortrait PageOrientation = "portrait"
+	// OrientationLandscape 横
+	OrientationLandscape PageOrientation = "landscape"
+)
+
+const (
+	// PaperLetter レター
+	PaperLetter = 1
+	// PaperA3 A3
+	PaperA3 = 8
+	// PaperA4 A4
+	PaperA4 = 9
+	// PaperB4 B4(JIS)
+	PaperB4 = 12
+	// PaperB5 B5(JIS)
+	PaperB5 = 13
+)
+
+// PageMargins 余白(インチ)
+type PageMargins struct {
+	Left   float64
+	Right  float64
+	Top    float64
+	Bottom float64
+	Header float64
+	Footer float64
+}
+
+// HeaderFooter ヘッダーとフッター
+// "&L"、"&C"、"&R"で位置を、"&P"でページ番号を、"&N"で総ページ数を指定できる
+type HeaderFooter struct {
+	Header string
+	Footer string
+}
+
+// PageSetup 印刷の設定
+// ゼロ値やnilの項目はシートの既存の設定を変更しない
+type PageSetup struct {
+	// Orientation 用紙の向き
+	Orientation PageOrientation
+	// PaperSize 用紙サイズ(PaperA4など)
+	PaperSize int
+	// Scale 拡大縮小率(10から400)。指定した場合はページ数に合わせる設定を解除する
+	Scale int
+	// FitToWidth 横方向のページ数。FitToWidthかFitToHeightを指定した場合はページ数に合わせて印刷する
+	FitToWidth int
+	// FitToHeight 縦方向のページ数。FitToWidthのみ指定した場合は自動
+	FitToHeight int
+	// Margins 余白。nilの場合は変更しないが、シートに余白がない場合は既定の余白を設定する
+	Margins *PageMargins
+	// HeaderFooter ヘッダーとフッター。nilの場合は変更しない
+	HeaderFooter *HeaderFooter
+	// PrintArea 印刷範囲("A1:H50"など)。解除する場合はClearPrintAreaを使う
+	PrintArea string
+	// PrintTitleRows 各ページに印刷する行("1:2"など)。解除する場合はClearPrintTitlesを使う
+	PrintTitleRows string
+	// PrintTitleCols 各ページに印刷する列("A:B"など)。行だけ指定した場合は既存の列を残す
+	PrintTitleCols string
+	// HorizontalCentered 水平方向の中央に印刷する。nilの場合は変更しない
+	HorizontalCentered *bool
+	// VerticalCentered 垂直方向の中央に印刷する。nilの場合は変更しない
+	VerticalCentered *bool
+	// PrintGridLines 枠線を印刷する。nilの場合は変更しない
+	PrintGridLines *bool
+	// PrintHeadings 行番号と列番号を印刷する。nilの場合は変更しない
+	PrintHeadings *bool
+}
+
+// SetPageSetup set the page setup and print options of the sheet
+// Fields with zero values or nil do not change the existing settings of the sheet,
+// but the default margins are set if the sheet has no margins.
+// The print area and the print titles are set as the defined names of the sheet.
+// Use ClearPrintArea and ClearPrintTitles to clear them.
+func (sheet *Sheet) SetPageSetup(setup PageSetup) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	switch setup.Orientation {
+	case OrientationDefault, OrientationPortrait, OrientationLandscape:
+	default:
+		return errors.New("The orientation [" + string(setup.Orientation) + "] is invalid.")
+	}
+	if setup.PaperSize < 0 {
+		return errors.New("The paper size should not be negative.")
+	}
+	if setup.Scale != 0 && (setup.Scale < 10 || setup.Scale > 400) {
+		return errors.New("The scale should be between 10 and 400.")
+	}
+	if setup.FitToWidth < 0 || setup.FitToHeight < 0 {
+		return errors.New("The number of pages should not be negative.")
+	}
+	var printArea string
+	if setup.PrintArea != "" {
+		r, err := parseRange(setup.PrintArea)
+		if err != nil {
+			return err
+		}
+		printArea = absoluteRef(sheet.xml.Name, r)
+	}
+	rows, cols := setup.PrintTitleRows, setup.PrintTitleCols
+	if (rows == "") != (cols == "") && sheet.workbook != nil {
+		// 行と列の一方だけ指定した場合は既存の印刷タイトルのもう一方を残す
+		oldRows, oldCols := sheet.workbook.printTitles(sheet.workbook.sheetIndex(sheet))
+		if rows == "" {
+			rows = oldRows
+		} else {
+			cols = oldCols
+		}
+	}
+	printTitles, err := printTitlesRef(sheet.xml.Name, rows, cols)
+	if err != nil {
+		return err
+	}
+	fitToPage := sheet.fitToPage()
+	if setup.FitToWidth > 0 || setup.FitToHeight > 0 {
+		fitToPage = true
+	} else if setup.Scale != 0 {
+		fitToPage = false
+	}
+	if fitToPage != sheet.fitToPage() {
+		if sheet.headerOutput {
+			return errors.New("The fit to page cannot be changed after rows are output.")
+		}
+		sheet.setFitToPage(fitToPage)
+	}
+
+	options := sheet.worksheet.getChild("printOptions")
+	if options == nil {
+		options = &Tag{Name: xml.Name{Local: "printOptions"}}
+	}
+	setBoolAttr(options, "horizontalCentered", setup.HorizontalCentered)
+	setBoolAttr(options, "verticalCentered", setup.VerticalCentered)
+	setBoolAttr(options, "headings", setup.PrintHeadings)
+	setBoolAttr(options, "gridLines", setup.PrintGridLines)
+	if len(options.Attr) == 0 {
+		sheet.worksheet.removeChild("printOptions")
+	} else {
+		sheet.worksheet.insertChild(options, worksheetOrder)
+	}
+
+	if setup.Margins != nil || sheet.worksheet.getChild("pageMargins") == nil {
+		margins := setup.Margins
+		if margins == nil {
+			margins = &PageMargins{Left: 0.7, Right: 0.7, Top: 0.75, Bottom: 0.75, Header: 0.3, Footer: 0.3}
+		}
+		tag := &Tag{Name: xml.Name{Local: "pageMargins"}}
+		tag.setAttr("left", strconv.FormatFloat(margins.Left, 'f', -1, 64))
+		tag.setAttr("right", strconv.FormatFloat(margins.Right, 'f', -1, 64))
+		tag.setAttr("top", strconv.FormatFloat(margins.Top, 'f', -1, 64))
+		tag.setAttr("bottom", strconv.FormatFloat(margins.Bottom, 'f', -1, 64))
+		tag.setAttr("header", strconv.FormatFloat(margins.Header, 'f', -1, 64))
+		tag.setAttr("footer", strconv.FormatFloat(margins.Footer, 'f', -1, 64))
+		sheet.worksheet.insertChild(tag, worksheetOrder)
+	}
+
+	// プリンタの設定(r:id)などは残して変更する
+	pageSetup := sheet.worksheet.getChild("pageSetup")
+	if pageSetup == nil {
+		pageSetup = &Tag{Name: xml.Name{Local: "pageSetup"}}
+	}
+	setIntAttr(pageSetup, "paperSize", setup.PaperSize)
+	setIntAttr(pageSetup, "scale", setup.Scale)
+	if setup.FitToWidth > 0 || setup.FitToHeight > 0 {
+		pageSetup.setAttr("fitToWidth", strconv.Itoa(setup.FitToWidth))
+		pageSetup.setAttr("fitToHeight", strconv.Itoa(setup.FitToHeight))
+	} else if !fitToPage {
+		pageSetup.deleteAttr("fitToWidth")
+		pageSetup.deleteAttr("fitToHeight")
+	}
+	if setup.Orientation != OrientationDefault {
+		pageSetup.setAttr("orientation", string(setup.Orientation))
+	}
+	if len(pageSetup.Attr) != 0 {
+		sheet.worksheet.insertChild(pageSetup, worksheetOrder)
+	}
+
+	if setup.HeaderFooter != nil {
+		tag := &Tag{Name: xml.Name{Local: "headerFooter"}}
+		if setup.HeaderFooter.Header != "" {
+			tag.Children = append(tag.Children, &Tag{Name: xml.Name{Local: "oddHeader"}, Children: []interface{}{xml.CharData(setup.HeaderFooter.Header)}})
+		}
+		if setup.HeaderFooter.Footer != "" {
+			tag.Children = append(tag.Children, &Tag{Name: xml.Name{Local: "oddFooter"}, Children: []interface{}{xml.CharData(setup.HeaderFooter.Footer)}})
+		}
+		if len(tag.Children) == 0 {
+			sheet.worksheet.removeChild("headerFooter")
+		} else {
+			sheet.worksheet.insertChild(tag, worksheetOrder)
+		}
+	}
+
+	if sheet.workbook != nil {
+		id := sheet.workbook.sheetIndex(sheet)
+		if printArea != "" {
+			sheet.workbook.setDefinedName(printAreaName, id, printArea, false)
+		}
+		if printTitles != "" {
+			sheet.workbook.setDefinedName(printTitlesName, id, printTitles, false)
+		}
+	}
+	return nil
+}
+
+// ClearPrintArea clear the print area of the sheet
+func (sheet *Sheet) ClearPrintArea() error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	if sheet.workbook != nil {
+		sheet.workbook.deleteDefinedName(printAreaName, sheet.workbook.sheetIndex(sheet))
+	}
+	return nil
+}
+
+// ClearPrintTitles clear the rows and columns printed on every page
+func (sheet *Sheet) ClearPrintTitles() error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	if sheet.workbook != nil {
+		sheet.workbook.deleteDefinedName(printTitlesName, sheet.workbook.sheetIndex(sheet))
+	}
+	return nil
+}
+
+// printTitles シートの印刷タイトルの行("1:2"など)と列("A:B"など)を取得する
+func (workbook *Workbook) printTitles(id int) (string, string) {
+	tag := workbook.findDefinedName(printTitlesName, id)
+	if tag == nil {
+		return "", ""
+	}
+	_, ref, err := parseNameRef(tag.text())
+	if err != nil {
+		return "", ""
+	}
+	var rows, cols string
+	for _, area := range strings.Split(ref, ",") {
+		if titleRowsPattern.MatchString(area) {
+			rows = area
+		} else if titleColsPattern.MatchString(area) {
+			cols = area
+		}
+	}
+	return rows, cols
+}
+
+// printTitlesRef "1:2"のような行と"A:B"のような列から印刷タイトルの参照を作成する
+func printTitlesRef(sheetName string, rows string, cols string) (string, error) {
+	ref := ""
+	if cols != "" {
+		strs := titleColsPattern.FindStringSubmatch(strings.ToUpper(cols))
+		if strs == nil || ColNumPosition(strs[1]) > ColNumPosition(strs[2]) {
+			return "", errors.New("The columns [" + cols + "] are invalid.")
+		}
+		ref = quoteSheetName(sheetName) + "!$" + strs[1] + ":$" + strs[2]
+	}
+	if rows != "" {
+		strs := titleRowsPattern.FindStringSubmatch(rows)
+		if strs == nil {
+			return "", errors.New("The rows [" + rows + "] are invalid.")
+		}
+		from, _ := strconv.Atoi(strs[1])
+		to, _ := strconv.Atoi(strs[2])
+		if from <= 0 || from > to {
+			return "", errors.New("The rows [" + rows + "] are invalid.")
+		}
+		if ref != "" {
+			ref += ","
+		}
+		ref += quoteSheetName(sheetName) + "!$" + strs[1] + ":$" + strs[2]
+	}
+	return ref, nil
+}
+
+// fitToPage ページ数に合わせて印刷する設定か確認する
+func (sheet *Sheet) fitToPage() bool {
+	if sheetPr := sheet.worksheet.getChild("sheetPr"); sheetPr != nil {
+		if pr := sheetPr.getChild("pageSetUpPr"); pr != nil {
+			fit, _ := pr.getAttr("fitToPage")
+			return fit == "1" || fit == "true"
+		}
+	}
+	return false
+}
+
+// setFitToPage sheetPrのpageSetUpPrにページ数に合わせて印刷するかを設定する
+func (sheet *Sheet) setFitToPage(fit bool) {
+	sheetPr := sheet.worksheet.getChild("sheetPr")
+	if sheetPr == nil {
+		if !fit {
+			return
+		}
+		sheetPr = &Tag{Name: xml.Name{Local: "sheetPr"}}
+		sheet.worksheet.insertChild(sheetPr, worksheetOrder)
+	}
+	pr := sheetPr.getChild("pageSetUpPr")
+	if pr == nil {
+		pr = &Tag{Name: xml.Name{Local: "pageSetUpPr"}}
+		sheetPr.insertChild(pr, sheetPrOrder)
+	}
+	if fit {
+		pr.setAttr("fitToPage", "1")
+	} else {
+		pr.deleteAttr("fitToPage")
+	}
+}
+
+// setBoolAttr trueの場合は"1"を設定し、falseの場合は属性を削除する。nilの場合は変更しない
+func setBoolAttr(tag *Tag, name string, val *bool) {
+	if val == nil {
+		return
+	}
+	if *val {
+		tag.setAttr(name, "1")
+	} else {
+		tag.deleteAttr(name)
+	}
+}
+
+// setIntAttr 0以外の場合は値を設定する。0の場合は変更しない
+func setIntAttr(tag *Tag, name string, val int) {
+	if val != 0 {
+		tag.setAttr(name, strconv.Itoa(val))
+	}
+}
@@ -0,0 +1,164 @@
+package excl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSetPageSetup(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	workbook.OpenSheet("Sheet1")
+	sheet, _ := workbook.OpenSheet("Report 1")
+	on := true
+	err := sheet.SetPageSetup(PageSetup{
+		Orientation:        OrientationLandscape,
+		PaperSize:          PaperA4,
+		FitToWidth:         1,
+		Margins:            &PageMargins{Left: 0.5, Right: 0.5, Top: 1, Bottom: 1, Header: 0.25, Footer: 0.25},
+		HeaderFooter:       &HeaderFooter{Header: "&CMonthly Report", Footer: "&P / &N"},
+		PrintArea:          "A1:H50",
+		PrintTitleRows:     "1:2",
+		PrintTitleCols:     "a:b",
+		HorizontalCentered: &on,
+		PrintGridLines:     &on,
+	})
+	if err != nil {
+		t.Fatal("page setup should be set.", err.Error())
+	}
+	if err = sheet.SetPageSetup(PageSetup{Scale: 5}); err == nil {
+		t.Error("invalid scale should be error.")
+	}
+	if err = sheet.SetPageSetup(PageSetup{Orientation: "upside"}); err == nil {
+		t.Error("invalid orientation should be error.")
+	}
+	if err = sheet.SetPageSetup(PageSetup{PrintTitleRows: "3:1"}); err == nil {
+		t.Error("invalid print title rows should be error.")
+	}
+	sheet.Close()
+
+	str := readPart(workbook, "xl/worksheets/sheet2.xml")
+	expected := []string{
+		`<sheetPr><pageSetUpPr fitToPage="1"></pageSetUpPr></sheetPr><sheetViews>`,
+		`</sheetData><printOptions horizontalCentered="1" gridLines="1"></printOptions>` +
+			`<pageMargins left="0.5" right="0.5" top="1" bottom="1" header="0.25" footer="0.25"></pageMargins>` +
+			`<pageSetup paperSize="9" fitToWidth="1" fitToHeight="0" orientation="landscape"></pageSetup>` +
+			`<headerFooter><oddHeader>&amp;CMonthly Report</oddHeader><oddFooter>&amp;P / &amp;N</oddFooter></headerFooter>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(str, e) {
+			t.Error("sheet should contain", e, str)
+		}
+	}
+	names := workbook.DefinedNames()
+	if len(names) != 2 || names[0] != (DefinedName{Name: "_xlnm.Print_Area", RefersTo: "'Report 1'!$A$1:$H$50", Scope: "Report 1"}) ||
+		names[1] != (DefinedName{Name: "_xlnm.Print_Titles", RefersTo: "'Report 1'!$A:$B,'Report 1'!$1:$2", Scope: "Report 1"}) {
+		t.Error("print area and print titles should be defined.", names)
+	}
+
+	// ゼロ値の項目は変更しない
+	sheet, _ = workbook.OpenSheet("Report 1")
+	if err = sheet.SetPageSetup(PageSetup{}); err != nil {
+		t.Fatal("page setup should be set.", err.Error())
+	}
+	sheet.Close()
+	if str = readPart(workbook, "xl/worksheets/sheet2.xml"); !strings.Contains(str, expected[0]) || !strings.Contains(str, expected[1]) {
+		t.Error("page setup should not be changed by zero values.", str)
+	}
+	if names = workbook.DefinedNames(); len(names) != 2 {
+		t.Error("print area and print titles should be kept.", names)
+	}
+
+	// 拡大縮小率を指定するとページ数に合わせる設定は解除される
+	sheet, _ = workbook.OpenSheet("Report 1")
+	if err = sheet.SetPageSetup(PageSetup{Scale: 80, Orientation: OrientationPortrait}); err != nil {
+		t.Fatal("page setup should be set.", err.Error())
+	}
+	sheet.Close()
+	str = readPart(workbook, "xl/worksheets/sheet2.xml")
+	if strings.Contains(str, `fitToPage="1"`) || !strings.Contains(str, `<pageSetup paperSize="9" orientation="portrait" scale="80"></pageSetup>`) {
+		t.Error("fit to page should be replaced with the scale.", str)
+	}
+}
+
+func TestSetPageSetupOfTemplate(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	on := true
+	sheet.SetPageSetup(PageSetup{Orientation: OrientationLandscape, PaperSize: PaperA3, Scale: 75, PrintGridLines: &on, PrintHeadings: &on, PrintTitleRows: "1:1", PrintTitleCols: "A:A"})
+	sheet.Close()
+	var buf bytes.Buffer
+	if _, err := workbook.WriteTo(&buf); err != nil {
+		t.Fatal("workbook should be written.", err.Error())
+	}
+	workbook.Close()
+
+	workbook, _ = OpenBytes(buf.Bytes())
+	defer workbook.Close()
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	if err := sheet.SetPageSetup(PageSetup{PrintArea: "A1:F20"}); err != nil {
+		t.Fatal("print area should be set.", err.Error())
+	}
+	sheet.Close()
+	str := readPart(workbook, "xl/worksheets/sheet1.xml")
+	if !strings.Contains(str, `<printOptions headings="1" gridLines="1"></printOptions>`) ||
+		!strings.Contains(str, `<pageSetup paperSize="8" scale="75" orientation="landscape"></pageSetup>`) {
+		t.Error("page setup of the template should be kept.", str)
+	}
+	names := workbook.DefinedNames()
+	if len(names) != 2 || names[0].RefersTo != "Sheet1!$A:$A,Sheet1!$1:$1" || names[1].RefersTo != "Sheet1!$A$1:$F$20" {
+		t.Error("print area should be defined and print titles should be kept.", names)
+	}
+
+	// 枠線の印刷を解除し、印刷タイトルの行だけ変更する
+	off := false
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	if err := sheet.SetPageSetup(PageSetup{PrintGridLines: &off, PrintTitleRows: "1:2"}); err != nil {
+		t.Fatal("page setup should be set.", err.Error())
+	}
+	sheet.Close()
+	if str = readPart(workbook, "xl/worksheets/sheet1.xml"); !strings.Contains(str, `<printOptions headings="1"></printOptions>`) {
+		t.Error("grid lines should be turned off.", str)
+	}
+	if names = workbook.DefinedNames(); len(names) != 2 || names[0].RefersTo != "Sheet1!$A:$A,Sheet1!$1:$2" {
+		t.Error("columns of print titles should be kept.", names)
+	}
+
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	sheet.SetPageSetup(PageSetup{PrintHeadings: &off})
+	if err := sheet.ClearPrintArea(); err != nil {
+		t.Error("print area should be cleared.", err.Error())
+	}
+	if err := sheet.ClearPrintTitles(); err != nil {
+		t.Error("print titles should be cleared.", err.Error())
+	}
+	sheet.Close()
+	if str = readPart(workbook, "xl/worksheets/sheet1.xml"); strings.Contains(str, "<printOptions") {
+		t.Error("print options should be removed.", str)
+	}
+	if names = workbook.DefinedNames(); len(names) != 0 {
+		t.Error("print area and print titles should be cleared.", names)
+	}
+	if err := sheet.ClearPrintArea(); err == nil {
+		t.Error("closed sheet should be error.")
+	}
+}
+
+func TestSetPageSetupAfterOutput(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	sheet.GetRow(1).SetString("a", 1)
+	sheet.OutputAll()
+	if err := sheet.SetPageSetup(PageSetup{FitToWidth: 1}); err == nil {
+		t.Error("fit to page should not be changed after rows are output.")
+	}
+	if err := sheet.SetPageSetup(PageSetup{Orientation: OrientationLandscape}); err != nil {
+		t.Error("page setup after sheetData should be changed.", err.Error())
+	}
+	sheet.Close()
+	if str := readPart(workbook, "xl/worksheets/sheet1.xml"); !strings.Contains(str, `<pageMargins left="0.7" right="0.7" top="0.75" bottom="0.75" header="0.3" footer="0.3"></pageMargins><pageSetup orientation="landscape">`) {
+		t.Error("page setup should be written.", str)
+	}
+}
@@ -0,0 +1,148 @@
+package excl
+
+import (
+	"encoding/xml"
+	"errors"
+	"strconv"
+)
+
+// FreezePanes freeze the top rows and the left columns
+// FreezePanes(1, 0) freezes the header row. Panes are removed when both are 0.
+func (sheet *Sheet) FreezePanes(rows int, cols int) error {
+	if rows < 0 || cols < 0 {
+		return errors.New("The number of frozen rows and columns should not be negative.")
+	}
+	if rows == 0 && cols == 0 {
+		return sheet.Unfreeze()
+	}
+	view, err := sheet.getSheetView()
+	if err != nil {
+		return err
+	}
+	pane := &Tag{Name: xml.Name{Local: "pane"}}
+	if cols > 0 {
+		pane.setAttr("xSplit", strconv.Itoa(cols))
+	}
+	if rows > 0 {
+		pane.setAttr("ySplit", strconv.Itoa(rows))
+	}
+	pane.setAttr("topLeftCell", cellName(rows+1, cols+1))
+	pane.setAttr("activePane", activePaneName(rows > 0, cols > 0))
+	pane.setAttr("state", "frozen")
+	setPane(view, pane, rows > 0, cols > 0, rows+1, cols+1)
+	return nil
+}
+
+// SplitPanes split the window at the position in twips(1/20 point) from the top-left
+// x is the horizontal position and y is the vertical position. Panes are removed when both are 0.
+func (sheet *Sheet) SplitPanes(x int, y int) error {
+	if x < 0 || y < 0 {
+		return errors.New("The split position should not be negative.")
+	}
+	if x == 0 && y == 0 {
+		return sheet.Unfreeze()
+	}
+	view, err := sheet.getSheetView()
+	if err != nil {
+		return err
+	}
+	pane := &Tag{Name: xml.Name{Local: "pane"}}
+	if x > 0 {
+		pane.setAttr("xSplit", strconv.Itoa(x))
+	}
+	if y > 0 {
+		pane.setAttr("ySplit", strconv.Itoa(y))
+	}
+	pane.setAttr("activePane", activePaneName(y > 0, x > 0))
+	setPane(view, pane, y > 0, x > 0, 1, 1)
+	return nil
+}
+
+// Unfreeze remove frozen or split panes
+func (sheet *Sheet) Unfreeze() error {
+	view, err := sheet.getSheetView()
+	if err != nil {
+		return err
+	}
+	removePane(view)
+	return nil
+}
+
+// getSheetView 変更できるsheetViewタグを取得する。ない場合は作成する
+func (sheet *Sheet) getSheetView() (*Tag, error) {
+	if !sheet.opened {
+		return nil, errors.New("The sheet is not opened.")
+	}
+	if sheet.headerOutput {
+		return nil, errors.New("The sheet view cannot be changed after rows are output.")
+	}
+	if sheet.sheetView == nil {
+		sheet.sheetView = &Tag{Name: xml.Name{Local: "sheetView"}}
+		sheet.sheetView.setAttr("workbookViewId", "0")
+		views := &Tag{Name: xml.Name{Local: "sheetViews"}, Children: []interface{}{sheet.sheetView}}
+		sheet.worksheet.insertChild(views, worksheetOrder)
+	}
+	return sheet.sheetView, nil
+}
+
+// activePaneName 分割の方向から右下のペインの名前を取得する
+func activePaneName(horizontal bool, vertical bool) string {
+	switch {
+	case horizontal && vertical:
+		return "bottomRight"
+	case horizontal:
+		return "bottomLeft"
+	}
+	return "topRight"
+}
+
+// removePane paneタグとペインのselectionタグを削除する
+func removePane(view *Tag) {
+	var children []interface{}
+	for _, child := range view.Children {
+		if tag, ok := child.(*Tag); ok {
+			if tag.Name.Local == "pane" {
+				continue
+			}
+			if _, err := tag.getAttr("pane"); err == nil && tag.Name.Local == "selection" {
+				continue
+			}
+		}
+		children = append(children, child)
+	}
+	view.Children = children
+}
+
+// setPane sheetViewにpaneタグと各ペインのselectionタグを追加する
+// 行で分割した場合はbottomLeft、列で分割した場合はtopRightのselectionが必要になる
+func setPane(view *Tag, pane *Tag, horizontal bool, vertical bool, rowNo int, colNo int) {
+	removePane(view)
+	var children []interface{}
+	for _, child := range view.Children {
+		// ペインのないselectionは置き換える
+		if tag, ok := child.(*Tag); ok && tag.Name.Local == "selection" {
+			continue
+		}
+		children = append(children, child)
+	}
+	selections := []interface{}{pane}
+	if vertical {
+		selections = append(selections, selectionTag("topRight", cellName(1, colNo)))
+	}
+	if horizontal {
+		selections = append(selections, selectionTag("bottomLeft", cellName(rowNo, 1)))
+	}
+	if horizontal && vertical {
+		selections = append(selections, selectionTag("bottomRight", cellName(rowNo, colNo)))
+	}
+	view.Children = append(selections, children...)
+}
+
+// selectionTag selectionタグを作成する
+func selectionTag(pane string, cell string) *Tag {
+	tag := &Tag{Name: xml.Name{Local: "selection"}}
+	tag.setAttr("pane", pane)
+	tag.setAttr("activeCell", cell)
+	tag.setAttr("sqref", cell)
+	return tag
+}
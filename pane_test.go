@@ -0,0 +1,77 @@
+package excl
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func sheetViewString(sheet *Sheet) string {
+	var b bytes.Buffer
+	xml.NewEncoder(&b).Encode(sheet.sheetView)
+	return b.String()
+}
+
+func TestFreezePanes(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	if err := sheet.FreezePanes(1, 0); err != nil {
+		t.Fatal("panes should be frozen.", err.Error())
+	}
+	expected := `<sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"></pane><selection pane="bottomLeft" activeCell="A2" sqref="A2"></selection></sheetView>`
+	if str := sheetViewString(sheet); str != expected {
+		t.Error("sheetView should be", expected, "but", str)
+	}
+	sheet.FreezePanes(2, 1)
+	expected = `<sheetView workbookViewId="0"><pane xSplit="1" ySplit="2" topLeftCell="B3" activePane="bottomRight" state="frozen"></pane>` +
+		`<selection pane="topRight" activeCell="B1" sqref="B1"></selection><selection pane="bottomLeft" activeCell="A3" sqref="A3"></selection>` +
+		`<selection pane="bottomRight" activeCell="B3" sqref="B3"></selection></sheetView>`
+	if str := sheetViewString(sheet); str != expected {
+		t.Error("sheetView should be", expected, "but", str)
+	}
+	sheet.FreezePanes(0, 3)
+	expected = `<sheetView workbookViewId="0"><pane xSplit="3" topLeftCell="D1" activePane="topRight" state="frozen"></pane><selection pane="topRight" activeCell="D1" sqref="D1"></selection></sheetView>`
+	if str := sheetViewString(sheet); str != expected {
+		t.Error("sheetView should be", expected, "but", str)
+	}
+	sheet.SplitPanes(2000, 0)
+	expected = `<sheetView workbookViewId="0"><pane xSplit="2000" activePane="topRight"></pane><selection pane="topRight" activeCell="A1" sqref="A1"></selection></sheetView>`
+	if str := sheetViewString(sheet); str != expected {
+		t.Error("sheetView should be", expected, "but", str)
+	}
+	if err := sheet.FreezePanes(-1, 0); err == nil {
+		t.Error("negative rows should not be frozen.")
+	}
+	sheet.Unfreeze()
+	if str := sheetViewString(sheet); str != `<sheetView workbookViewId="0"></sheetView>` {
+		t.Error("panes should be removed but", str)
+	}
+	sheet.OutputThroughRowNo(1)
+	if err := sheet.FreezePanes(1, 0); err == nil {
+		t.Error("panes should not be frozen after rows are output.")
+	}
+}
+
+func TestFreezePanesWithoutSheetView(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	sheet.Close()
+	f, _ := workbook.storage.Create("xl/worksheets/sheet1.xml")
+	f.WriteString(`<worksheet><sheetPr></sheetPr><sheetData></sheetData></worksheet>`)
+	f.Close()
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	if err := sheet.FreezePanes(1, 1); err != nil {
+		t.Fatal("panes should be frozen.", err.Error())
+	}
+	sheet.Close()
+	var b bytes.Buffer
+	f, _ = workbook.storage.Open("xl/worksheets/sheet1.xml")
+	b.ReadFrom(f)
+	f.Close()
+	if !strings.HasPrefix(b.String(), `<worksheet><sheetPr></sheetPr><sheetViews><sheetView workbookViewId="0"><pane `) {
+		t.Error("sheetViews should be inserted after sheetPr.", b.String())
+	}
+}
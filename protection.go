@@ -0,0 +1,300 @@
+package excl
+
+import (
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"strconv"
+	"unicode/utf16"
+)
+
+const (
+	// passwordSpinCount パスワードのハッシュを繰り返す回数
+	passwordSpinCount = 100000
+	// passwordSaltSize パスワードのソルトのバイト数
+	passwordSaltSize = 16
+)
+
+// hashAlgorithms algorithmNameに対応するハッシュ関数
+var hashAlgorithms = map[string]func() hash.Hash{
+	"SHA-1":   sha1.New,
+	"SHA-256": sha256.New,
+	"SHA-384": sha512.New384,
+	"SHA-512": sha512.New,
+}
+
+// SheetProtection シートの保護の設定
+// 各項目をtrueにすると保護されたシートでもその操作ができる
+type SheetProtection struct {
+	AllowFormatCells      bool
+	AllowFormatColumns    bool
+	AllowFormatRows       bool
+	AllowInsertColumns    bool
+	AllowInsertRows       bool
+	AllowInsertHyperlinks bool
+	AllowDeleteColumns    bool
+	AllowDeleteRows       bool
+	AllowSort             bool
+	AllowAutoFilter       bool
+	AllowPivotTables      bool
+	AllowEditObjects      bool
+	AllowEditScenarios    bool
+	// DenySelectLockedCells ロックされたセルを選択できなくする
+	DenySelectLockedCells bool
+	// DenySelectUnlockedCells ロックされていないセルを選択できなくする
+	DenySelectUnlockedCells bool
+}
+
+// protectionAttr シートの保護の属性
+// 属性が"1"の場合は操作が禁止され、lockedは属性がない場合に禁止されるかを表す
+type protectionAttr struct {
+	name   string
+	locked bool
+	// deny フィールドがtrueの場合に禁止される
+	deny  bool
+	field func(p *SheetProtection) *bool
+}
+
+var protectionAttrs = []protectionAttr{
+	{"objects", false, false, func(p *SheetProtection) *bool { return &p.AllowEditObjects }},
+	{"scenarios", false, false, func(p *SheetProtection) *bool { return &p.AllowEditScenarios }},
+	{"formatCells", true, false, func(p *SheetProtection) *bool { return &p.AllowFormatCells }},
+	{"formatColumns", true, false, func(p *SheetProtection) *bool { return &p.AllowFormatColumns }},
+	{"formatRows", true, false, func(p *SheetProtection) *bool { return &p.AllowFormatRows }},
+	{"insertColumns", true, false, func(p *SheetProtection) *bool { return &p.AllowInsertColumns }},
+	{"insertRows", true, false, func(p *SheetProtection) *bool { return &p.AllowInsertRows }},
+	{"insertHyperlinks", true, false, func(p *SheetProtection) *bool { return &p.AllowInsertHyperlinks }},
+	{"deleteColumns", true, false, func(p *SheetProtection) *bool { return &p.AllowDeleteColumns }},
+	{"deleteRows", true, false, func(p *SheetProtection) *bool { return &p.AllowDeleteRows }},
+	{"selectLockedCells", false, true, func(p *SheetProtection) *bool { return &p.DenySelectLockedCells }},
+	{"sort", true, false, func(p *SheetProtection) *bool { return &p.AllowSort }},
+	{"autoFilter", true, false, func(p *SheetProtection) *bool { return &p.AllowAutoFilter }},
+	{"pivotTables", true, false, func(p *SheetProtection) *bool { return &p.AllowPivotTables }},
+	{"selectUnlockedCells", false, true, func(p *SheetProtection) *bool { return &p.DenySelectUnlockedCells }},
+}
+
+// Protect protect the sheet with the password
+// The password is stored as the SHA-512 hash and the legacy hash. The empty password is allowed.
+func (sheet *Sheet) Protect(password string, opts SheetProtection) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	tag := &Tag{Name: xml.Name{Local: "sheetProtection"}}
+	if password != "" {
+		hashValue, saltValue, err := newPasswordHash(password)
+		if err != nil {
+			return err
+		}
+		tag.setAttr("password", legacyPasswordHash(password))
+		tag.setAttr("algorithmName", "SHA-512")
+		tag.setAttr("hashValue", hashValue)
+		tag.setAttr("saltValue", saltValue)
+		tag.setAttr("spinCount", strconv.Itoa(passwordSpinCount))
+	}
+	tag.setAttr("sheet", "1")
+	for _, attr := range protectionAttrs {
+		prohibited := *attr.field(&opts) == attr.deny
+		if prohibited == attr.locked {
+			continue
+		}
+		if prohibited {
+			tag.setAttr(attr.name, "1")
+		} else {
+			tag.setAttr(attr.name, "0")
+		}
+	}
+	sheet.worksheet.insertChild(tag, worksheetOrder)
+	return nil
+}
+
+// Unprotect unprotect the sheet
+// An error is returned if the password does not match.
+func (sheet *Sheet) Unprotect(password string) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	tag := sheet.worksheet.getChild("sheetProtection")
+	if tag == nil {
+		return nil
+	}
+	ok, err := verifyPassword(tag, "", password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("The password is incorrect.")
+	}
+	sheet.worksheet.removeChild("sheetProtection")
+	return nil
+}
+
+// IsProtected check whether the sheet is protected
+func (sheet *Sheet) IsProtected() bool {
+	if !sheet.opened {
+		return false
+	}
+	tag := sheet.worksheet.getChild("sheetProtection")
+	return tag != nil && boolAttr(tag, "sheet", false)
+}
+
+// GetProtection get the protection settings of the sheet
+// nil is returned if the sheet is not protected.
+func (sheet *Sheet) GetProtection() (*SheetProtection, error) {
+	if !sheet.opened {
+		return nil, errors.New("The sheet is not opened.")
+	}
+	if !sheet.IsProtected() {
+		return nil, nil
+	}
+	tag := sheet.worksheet.getChild("sheetProtection")
+	p := &SheetProtection{}
+	for _, attr := range protectionAttrs {
+		*attr.field(p) = boolAttr(tag, attr.name, attr.locked) == attr.deny
+	}
+	return p, nil
+}
+
+// ProtectStructure protect the structure of the workbook with the password
+// Sheets cannot be added, deleted, renamed or moved in Excel.
+func (workbook *Workbook) ProtectStructure(password string) error {
+	tag := workbook.workbookTag.getChild("workbookProtection")
+	if tag == nil {
+		tag = &Tag{Name: xml.Name{Local: "workbookProtection"}}
+	}
+	for _, name := range []string{"workbookPassword", "workbookAlgorithmName", "workbookHashValue", "workbookSaltValue", "workbookSpinCount"} {
+		tag.deleteAttr(name)
+	}
+	if password != "" {
+		hashValue, saltValue, err := newPasswordHash(password)
+		if err != nil {
+			return err
+		}
+		tag.setAttr("workbookPassword", legacyPasswordHash(password))
+		tag.setAttr("workbookAlgorithmName", "SHA-512")
+		tag.setAttr("workbookHashValue", hashValue)
+		tag.setAttr("workbookSaltValue", saltValue)
+		tag.setAttr("workbookSpinCount", strconv.Itoa(passwordSpinCount))
+	}
+	tag.setAttr("lockStructure", "1")
+	workbook.workbookTag.insertChild(tag, workbookOrder)
+	return nil
+}
+
+// UnprotectStructure unprotect the structure of the workbook
+// An error is returned if the password does not match.
+func (workbook *Workbook) UnprotectStructure(password string) error {
+	tag := workbook.workbookTag.getChild("workbookProtection")
+	if tag == nil {
+		return nil
+	}
+	ok, err := verifyPassword(tag, "workbook", password)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("The password is incorrect.")
+	}
+	workbook.workbookTag.removeChild("workbookProtection")
+	return nil
+}
+
+// IsStructureProtected check whether the structure of the workbook is protected
+func (workbook *Workbook) IsStructureProtected() bool {
+	tag := workbook.workbookTag.getChild("workbookProtection")
+	return tag != nil && boolAttr(tag, "lockStructure", false)
+}
+
+// boolAttr 属性の真偽値を取得する。属性がない場合はdefを返す
+func boolAttr(tag *Tag, name string, def bool) bool {
+	val, err := tag.getAttr(name)
+	if err != nil {
+		return def
+	}
+	return val == "1" || val == "true"
+}
+
+// newPasswordHash ソルトを作成してパスワードのSHA-512のハッシュを取得する
+// ハッシュとソルトはBase64で返す
+func newPasswordHash(password string) (string, string, error) {
+	salt := make([]byte, passwordSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", "", err
+	}
+	h := passwordHash(sha512.New, password, salt, passwordSpinCount)
+	return base64.StdEncoding.EncodeToString(h), base64.StdEncoding.EncodeToString(salt), nil
+}
+
+// passwordHash ソルトとUTF-16LEのパスワードのハッシュを取得し、
+// 繰り返し回数分ハッシュと回数(リトルエンディアン)のハッシュを取る
+func passwordHash(newHash func() hash.Hash, password string, salt []byte, spinCount int) []byte {
+	data := make([]byte, 0, len(salt)+len(password)*2)
+	data = append(data, salt...)
+	for _, c := range utf16.Encode([]rune(password)) {
+		data = append(data, byte(c), byte(c>>8))
+	}
+	h := newHash()
+	h.Write(data)
+	sum := h.Sum(nil)
+	iterator := make([]byte, 4)
+	for i := 0; i < spinCount; i++ {
+		binary.LittleEndian.PutUint32(iterator, uint32(i))
+		h.Reset()
+		h.Write(sum)
+		h.Write(iterator)
+		sum = h.Sum(sum[:0])
+	}
+	return sum
+}
+
+// legacyPasswordHash 以前の形式の16ビットのパスワードのハッシュを取得する
+func legacyPasswordHash(password string) string {
+	chars := []rune(password)
+	if len(chars) > 15 {
+		chars = chars[:15]
+	}
+	hash := 0
+	for i := len(chars) - 1; i >= 0; i-- {
+		hash = ((hash >> 14) & 0x01) | ((hash << 1) & 0x7fff)
+		hash ^= int(chars[i] & 0xff)
+	}
+	hash = ((hash >> 14) & 0x01) | ((hash << 1) & 0x7fff)
+	hash ^= len(chars)
+	hash ^= 0xCE4B
+	return fmt.Sprintf("%04X", hash)
+}
+
+// verifyPassword 保護のタグのハッシュとパスワードが一致するか確認する
+// prefixはワークブックの場合は"workbook"、シートの場合は空にする
+func verifyPassword(tag *Tag, prefix string, password string) (bool, error) {
+	attr := func(name string) string {
+		if prefix != "" {
+			name = prefix + string(name[0]-'a'+'A') + name[1:]
+		}
+		val, _ := tag.getAttr(name)
+		return val
+	}
+	if hashValue := attr("hashValue"); hashValue != "" {
+		newHash, ok := hashAlgorithms[attr("algorithmName")]
+		if !ok {
+			return false, errors.New("The hash algorithm [" + attr("algorithmName") + "] is not supported.")
+		}
+		salt, err := base64.StdEncoding.DecodeString(attr("saltValue"))
+		if err != nil {
+			return false, err
+		}
+		spinCount, _ := strconv.Atoi(attr("spinCount"))
+		h := passwordHash(newHash, password, salt, spinCount)
+		return base64.StdEncoding.EncodeToString(h) == hashValue, nil
+	}
+	if legacy := attr("password"); legacy != "" {
+		return legacyPasswordHash(password) == legacy, nil
+	}
+	return true, nil
+}
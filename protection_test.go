@@ -0,0 +1,121 @@
+package excl
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+func TestProtectSheet(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	if sheet.IsProtected() {
+		t.Error("sheet should not be protected.")
+	}
+	if err := sheet.Protect("secret", SheetProtection{AllowSort: true, AllowAutoFilter: true, AllowFormatCells: true, DenySelectLockedCells: true}); err != nil {
+		t.Fatal("sheet should be protected.", err.Error())
+	}
+	if !sheet.IsProtected() {
+		t.Error("sheet should be protected.")
+	}
+	p, err := sheet.GetProtection()
+	if err != nil || p == nil || *p != (SheetProtection{AllowSort: true, AllowAutoFilter: true, AllowFormatCells: true, DenySelectLockedCells: true}) {
+		t.Error("protection settings should be read.", p, err)
+	}
+	tag := sheet.worksheet.getChild("sheetProtection")
+	if v, _ := tag.getAttr("password"); v != legacyPasswordHash("secret") {
+		t.Error("legacy hash should be written.", v)
+	}
+	if v, _ := tag.getAttr("spinCount"); v != "100000" {
+		t.Error("spin count should be 100000.", v)
+	}
+	if err = sheet.Unprotect("wrong"); err == nil {
+		t.Error("wrong password should be error.")
+	}
+	sheet.Close()
+
+	str := readPart(workbook, "xl/worksheets/sheet1.xml")
+	if !strings.Contains(str, `algorithmName="SHA-512"`) ||
+		!strings.Contains(str, `sheet="1" objects="1" scenarios="1" formatCells="0" selectLockedCells="1" sort="0" autoFilter="0"></sheetProtection>`) {
+		t.Error("sheet protection should be written.", str)
+	}
+	if strings.Index(str, "<sheetProtection") < strings.Index(str, "</sheetData>") {
+		t.Error("sheet protection should be written after sheetData.", str)
+	}
+
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	if !sheet.IsProtected() {
+		t.Error("existing protection should be detected.")
+	}
+	if err = sheet.Unprotect("secret"); err != nil {
+		t.Error("sheet should be unprotected.", err.Error())
+	}
+	if sheet.IsProtected() {
+		t.Error("sheet should not be protected.")
+	}
+	if err = sheet.Protect("", SheetProtection{}); err != nil {
+		t.Fatal("sheet should be protected without password.", err.Error())
+	}
+	if err = sheet.Unprotect("any"); err != nil {
+		t.Error("sheet without password should be unprotected.", err.Error())
+	}
+	sheet.Close()
+}
+
+func TestProtectStructure(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	workbook.OpenSheet("Sheet1")
+	if workbook.IsStructureProtected() {
+		t.Error("workbook should not be protected.")
+	}
+	if err := workbook.ProtectStructure("secret"); err != nil {
+		t.Fatal("workbook should be protected.", err.Error())
+	}
+	var buf bytes.Buffer
+	if _, err := workbook.WriteTo(&buf); err != nil {
+		t.Fatal("workbook should be written.", err.Error())
+	}
+	workbook, _ = OpenBytes(buf.Bytes())
+	defer workbook.Close()
+	str := readPart(workbook, "xl/workbook.xml")
+	if !strings.Contains(str, `<workbookProtection workbookPassword="`) || !strings.Contains(str, `workbookAlgorithmName="SHA-512"`) ||
+		strings.Index(str, "<workbookProtection") > strings.Index(str, "<sheets>") {
+		t.Error("workbook protection should be written before sheets.", str)
+	}
+	if !workbook.IsStructureProtected() {
+		t.Error("existing protection should be detected.")
+	}
+	if err := workbook.UnprotectStructure("wrong"); err == nil {
+		t.Error("wrong password should be error.")
+	}
+	if err := workbook.UnprotectStructure("secret"); err != nil || workbook.IsStructureProtected() {
+		t.Error("workbook should be unprotected.", err)
+	}
+}
+
+func TestPasswordHash(t *testing.T) {
+	if h := legacyPasswordHash("password"); h != "83AF" {
+		t.Error("legacy hash should be 83AF but", h)
+	}
+	salt := []byte("0123456789abcdef")
+	h1 := passwordHash(sha512.New, "パスワード", salt, 10)
+	h2 := passwordHash(sha512.New, "パスワード", salt, 11)
+	if len(h1) != 64 || bytes.Equal(h1, h2) {
+		t.Error("hash should depend on the spin count.")
+	}
+	tag := &Tag{}
+	tag.setAttr("algorithmName", "SHA-512")
+	tag.setAttr("hashValue", base64.StdEncoding.EncodeToString(h1))
+	tag.setAttr("saltValue", base64.StdEncoding.EncodeToString(salt))
+	tag.setAttr("spinCount", "10")
+	if ok, err := verifyPassword(tag, "", "パスワード"); !ok || err != nil {
+		t.Error("password should be verified.", err)
+	}
+	tag.setAttr("algorithmName", "MD5")
+	if _, err := verifyPassword(tag, "", "パスワード"); err == nil {
+		t.Error("unsupported algorithm should be error.")
+	}
+}
@@ -0,0 +1,80 @@
+package excl
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var cellRefPattern = regexp.MustCompile(`^\$?([A-Z]+)\$?([0-9]+)$`)
+
+// cellRange "A1:D3"のようなセル範囲
+type cellRange struct {
+	minRow int
+	minCol int
+	maxRow int
+	maxCol int
+}
+
+// parseCellRef "B3"のようなセルの位置から行番号と列番号を取得する
+func parseCellRef(ref string) (int, int, error) {
+	strs := cellRefPattern.FindStringSubmatch(strings.ToUpper(strings.TrimSpace(ref)))
+	if len(strs) != 3 {
+		return 0, 0, errors.New("The cell [" + ref + "] is invalid.")
+	}
+	rowNo, _ := strconv.Atoi(strs[2])
+	if rowNo <= 0 {
+		return 0, 0, errors.New("The cell [" + ref + "] is invalid.")
+	}
+	return rowNo, ColNumPosition(strs[1]), nil
+}
+
+// parseRange "A1:D3"のようなセル範囲を取得する。"A1"は1セルの範囲になる
+func parseRange(ref string) (cellRange, error) {
+	parts := strings.Split(ref, ":")
+	if len(parts) > 2 {
+		return cellRange{}, errors.New("The range [" + ref + "] is invalid.")
+	}
+	row1, col1, err := parseCellRef(parts[0])
+	if err != nil {
+		return cellRange{}, errors.New("The range [" + ref + "] is invalid.")
+	}
+	row2, col2 := row1, col1
+	if len(parts) == 2 {
+		if row2, col2, err = parseCellRef(parts[1]); err != nil {
+			return cellRange{}, errors.New("The range [" + ref + "] is invalid.")
+		}
+	}
+	r := cellRange{minRow: row1, minCol: col1, maxRow: row2, maxCol: col2}
+	if r.minRow > r.maxRow {
+		r.minRow, r.maxRow = r.maxRow, r.minRow
+	}
+	if r.minCol > r.maxCol {
+		r.minCol, r.maxCol = r.maxCol, r.minCol
+	}
+	return r, nil
+}
+
+// cellName 行番号と列番号から"B3"のようなセルの位置を取得する
+func cellName(rowNo int, colNo int) string {
+	return ColStringPosition(colNo) + strconv.Itoa(rowNo)
+}
+
+// String "A1:D3"の形式で範囲を返す。1セルの場合は"A1"
+func (r cellRange) String() string {
+	if r.minRow == r.maxRow && r.minCol == r.maxCol {
+		return cellName(r.minRow, r.minCol)
+	}
+	return cellName(r.minRow, r.minCol) + ":" + cellName(r.maxRow, r.maxCol)
+}
+
+// contains セルが範囲に含まれるか確認する
+func (r cellRange) contains(rowNo int, colNo int) bool {
+	return r.minRow <= rowNo && rowNo <= r.maxRow && r.minCol <= colNo && colNo <= r.maxCol
+}
+
+// overlaps 範囲が重なるか確認する
+func (r cellRange) overlaps(o cellRange) bool {
+	return r.minRow <= o.maxRow && o.minRow <= r.maxRow && r.minCol <= o.maxCol && o.minCol <= r.maxCol
+}
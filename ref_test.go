@@ -0,0 +1,44 @@
+package excl
+
+import "testing"
+
+func TestParseCellRef(t *testing.T) {
+	if rowNo, colNo, err := parseCellRef("b3"); err != nil || rowNo != 3 || colNo != 2 {
+		t.Error("cell should be row 3 and col 2 but", rowNo, colNo, err)
+	}
+	if rowNo, colNo, err := parseCellRef(" $AA$10 "); err != nil || rowNo != 10 || colNo != 27 {
+		t.Error("cell should be row 10 and col 27 but", rowNo, colNo, err)
+	}
+	for _, ref := range []string{"", "3B", "A0", "A", "A-1", "B2:C3"} {
+		if _, _, err := parseCellRef(ref); err == nil {
+			t.Error("cell [", ref, "] should be invalid.")
+		}
+	}
+}
+
+func TestParseRange(t *testing.T) {
+	r, err := parseRange("D3:$B$1")
+	if err != nil {
+		t.Fatal("range should be parsed.", err.Error())
+	}
+	if r.String() != "B1:D3" {
+		t.Error("range should be B1:D3 but", r.String())
+	}
+	if !r.contains(2, 3) || r.contains(4, 3) {
+		t.Error("contains is invalid.")
+	}
+	if !r.overlaps(cellRange{minRow: 3, minCol: 4, maxRow: 5, maxCol: 5}) {
+		t.Error("D3:E5 should overlap B1:D3.")
+	}
+	if r.overlaps(cellRange{minRow: 4, minCol: 1, maxRow: 5, maxCol: 5}) {
+		t.Error("A4:E5 should not overlap B1:D3.")
+	}
+	if r, _ = parseRange("C5"); r.String() != "C5" {
+		t.Error("range should be C5 but", r.String())
+	}
+	for _, ref := range []string{"A1:B2:C3", "A1:", "1A:B2"} {
+		if _, err = parseRange(ref); err == nil {
+			t.Error("range [", ref, "] should be invalid.")
+		}
+	}
+}
@@ -20,6 +20,7 @@ type Row struct {
 	minColNo      int
 	maxColNo      int
 	styles        *Styles
+	sheet         *Sheet
 }
 
 // NewRow は新しく行を追加する際に使用する
@@ -41,6 +42,7 @@ func NewRow(tag *Tag, sharedStrings *SharedStrings, styles *Styles) *Row {
 					return nil
 				}
 				cell.styles = row.styles
+				cell.row = row
 				row.cells = append(row.cells, cell)
 				row.maxColNo = cell.colNo
 				if row.minColNo == 0 {
@@ -89,7 +91,7 @@ func (row *Row) CreateCells(from int, to int) []*Cell {
 				}
 			}
 		}
-		cells[i-1] = &Cell{cell: tag, colNo: i, sharedStrings: row.sharedStrings, styleIndex: style, styles: row.styles}
+		cells[i-1] = &Cell{cell: tag, colNo: i, sharedStrings: row.sharedStrings, styleIndex: style, styles: row.styles, row: row}
 	}
 	row.cells = cells
 	return row.cells
@@ -97,14 +99,17 @@ func (row *Row) CreateCells(from int, to int) []*Cell {
 
 // GetCell セル番号のセルを取得する
 func (row *Row) GetCell(colNo int) *Cell {
-
+	pos := 0
 	for i := len(row.cells) - 1; i >= 0; i-- {
-		//	for _, cell := range row.cells {
 		cell := row.cells[i]
+		if cell == nil {
+			continue
+		}
 		if cell.colNo == colNo {
 			return cell
 		}
 		if cell.colNo < colNo {
+			pos = i + 1
 			break
 		}
 	}
@@ -118,11 +123,44 @@ func (row *Row) GetCell(colNo int) *Cell {
 		tag.setAttr("s", style)
 	}
 
+	// 列順を保つように挿入する
 	cell := NewCell(tag, row.sharedStrings, row.styles)
-	row.cells = append(row.cells, cell)
+	cell.row = row
+	row.cells = append(row.cells, nil)
+	copy(row.cells[pos+1:], row.cells[pos:])
+	row.cells[pos] = cell
 	return cell
 }
 
+// findCell 存在するセルを取得する。存在しない場合はnilを返す
+func (row *Row) findCell(colNo int) *Cell {
+	for _, cell := range row.cells {
+		if cell != nil && cell.colNo == colNo {
+			return cell
+		}
+	}
+	return nil
+}
+
+// GetRowNo get the row number(from 1)
+func (row *Row) GetRowNo() int {
+	return row.rowID
+}
+
+// GetCells get the cells which exist in the row in column order
+func (row *Row) GetCells() []*Cell {
+	var cells []*Cell
+	for _, cell := range row.cells {
+		if cell != nil {
+			cells = append(cells, cell)
+		}
+	}
+	sort.Slice(cells, func(i, j int) bool {
+		return cells[i].colNo < cells[j].colNo
+	})
+	return cells
+}
+
 // SetString set string at a row
 func (row *Row) SetString(val string, colNo int) *Cell {
 	cell := row.GetCell(colNo).SetString(val)
@@ -135,6 +173,12 @@ func (row *Row) SetNumber(val interface{}, colNo int) *Cell {
 	return cell
 }
 
+// SetBool set a boolean at a row
+func (row *Row) SetBool(val bool, colNo int) *Cell {
+	cell := row.GetCell(colNo).SetBool(val)
+	return cell
+}
+
 // SetFormula set a formula at a row
 func (row *Row) SetFormula(val string, colNo int) *Cell {
 	cell := row.GetCell(colNo).SetFormula(val)
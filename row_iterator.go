@@ -0,0 +1,134 @@
+package excl
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+)
+
+// RowIterator reads rows of a sheet one by one
+// Only one row is kept in memory so that sheets of any size can be read.
+type RowIterator struct {
+	sheet   *Sheet
+	file    File
+	decoder *xml.Decoder
+	row     *Row
+	err     error
+	inData  bool
+	done    bool
+}
+
+// RowIterator create an iterator which reads rows in sheetData
+// The sheet must not be opened because an opened sheet is being rewritten.
+func (sheet *Sheet) RowIterator() (*RowIterator, error) {
+	if sheet.opened {
+		return nil, errors.New("The sheet [" + sheet.xml.Name + "] is opened.")
+	}
+	if sheet.storage == nil {
+		return nil, errors.New("The sheet [" + sheet.xml.Name + "] has no storage.")
+	}
+	f, err := sheet.storage.Open(sheet.partName())
+	if err != nil {
+		return nil, err
+	}
+	return &RowIterator{sheet: sheet, file: f, decoder: xml.NewDecoder(f)}, nil
+}
+
+// Next read the next row
+// false is returned when there are no more rows or an error occurred.
+func (it *RowIterator) Next() bool {
+	it.row = nil
+	if it.done {
+		return false
+	}
+	for {
+		token, err := it.decoder.Token()
+		if err != nil {
+			if err != io.EOF {
+				it.err = err
+			} else if !it.inData {
+				it.err = errors.New("The file[" + it.sheet.target + "] is currupt. No sheetData tag found.")
+			}
+			it.done = true
+			return false
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "sheetData" {
+				it.inData = true
+			} else if it.inData && t.Name.Local == "row" {
+				tag := &Tag{}
+				if err = it.decoder.DecodeElement(tag, &t); err != nil {
+					it.err = err
+					it.done = true
+					return false
+				}
+				row := NewRow(tag, it.sheet.sharedStrings, it.sheet.Styles)
+				if row == nil {
+					it.err = errors.New("The file[" + it.sheet.target + "] is currupt.")
+					it.done = true
+					return false
+				}
+				it.row = row
+				return true
+			}
+		case xml.EndElement:
+			if t.Name.Local == "sheetData" {
+				it.done = true
+				return false
+			}
+		}
+	}
+}
+
+// Row get the current row
+func (it *RowIterator) Row() *Row {
+	return it.row
+}
+
+// Err get the error which occurred while reading rows
+func (it *RowIterator) Err() error {
+	return it.err
+}
+
+// Close close the sheet file
+func (it *RowIterator) Close() error {
+	if it.file == nil {
+		return nil
+	}
+	err := it.file.Close()
+	it.file = nil
+	it.done = true
+	return err
+}
+
+// eachRow call fn for each row of the sheet
+// Rows in memory are used when the sheet is opened, otherwise rows are read from the sheet file.
+// An error is returned if some rows of the opened sheet are already output.
+func (sheet *Sheet) eachRow(fn func(*Row) error) error {
+	if sheet.opened {
+		if sheet.outputRowNo > 0 {
+			return errors.New("The rows of the sheet [" + sheet.xml.Name + "] are already output.")
+		}
+		for _, row := range sheet.Rows {
+			if row == nil {
+				continue
+			}
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	it, err := sheet.RowIterator()
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for it.Next() {
+		if err = fn(it.Row()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
@@ -0,0 +1,102 @@
+package excl
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestRowIterator(t *testing.T) {
+	os.MkdirAll("temp/xl/worksheets", 0755)
+	defer os.RemoveAll("temp/xl")
+	f, _ := os.Create("temp/xl/worksheets/sheet1.xml")
+	f.WriteString(`<worksheet><sheetData><row r="1"><c r="A1" t="inlineStr"><is><t>hello</t></is></c><c r="C1"><v>3</v></c></row><row r="3"><c r="B3" t="b"><v>1</v></c></row></sheetData></worksheet>`)
+	f.Close()
+	sheet := newSheet("Sheet1", 0, "rId1", "worksheets/sheet1.xml")
+	sheet.storage = newDirStorage("temp")
+	it, err := sheet.RowIterator()
+	if err != nil {
+		t.Fatal("iterator should be created.", err.Error())
+	}
+	var rows []*Row
+	for it.Next() {
+		rows = append(rows, it.Row())
+	}
+	it.Close()
+	if it.Err() != nil {
+		t.Error("error should not be happen but", it.Err().Error())
+	}
+	if len(rows) != 2 {
+		t.Fatal("row count should be 2 but", len(rows))
+	}
+	if rows[0].GetRowNo() != 1 || rows[1].GetRowNo() != 3 {
+		t.Error("row no should be 1 and 3 but", rows[0].GetRowNo(), rows[1].GetRowNo())
+	}
+	cells := rows[0].GetCells()
+	if len(cells) != 2 || cells[0].GetColNo() != 1 || cells[1].GetColNo() != 3 {
+		t.Error("cells should be A1 and C1")
+	}
+	if v, _ := cells[0].GetString(); v != "hello" {
+		t.Error("value should be hello but", v)
+	}
+	if v, _ := rows[1].GetCell(2).GetBool(); !v {
+		t.Error("value should be true")
+	}
+
+	f, _ = os.Create("temp/xl/worksheets/sheet1.xml")
+	f.WriteString(`<worksheet></worksheet>`)
+	f.Close()
+	it, _ = sheet.RowIterator()
+	if it.Next() {
+		t.Error("row should not be found.")
+	}
+	if it.Err() == nil {
+		t.Error("error should be happen because sheetData does not exist.")
+	}
+	it.Close()
+
+	sheet.opened = true
+	if _, err = sheet.RowIterator(); err == nil {
+		t.Error("iterator should not be created because sheet is opened.")
+	}
+}
+
+func TestStreamRows(t *testing.T) {
+	os.MkdirAll("temp/out", 0755)
+	defer os.RemoveAll("temp/out")
+	w, _ := Create()
+	s, _ := w.OpenSheet("Sheet1")
+	for i := 1; i <= 100; i++ {
+		s.GetRow(i).SetNumber(i, 1)
+		s.GetRow(i).SetString("row", 2)
+	}
+	s.Close()
+	w.Save("temp/out/stream.xlsx")
+
+	w, _ = Open("temp/out/stream.xlsx")
+	defer w.Close()
+	count := 0
+	err := w.StreamRows("Sheet1", func(row *Row) error {
+		count++
+		if v, _ := row.GetCell(1).GetNumber(); int(v) != row.GetRowNo() {
+			t.Error("value should be", row.GetRowNo(), "but", v)
+		}
+		if v, _ := row.GetCell(2).GetString(); v != "row" {
+			t.Error("value should be row but", v)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Error("error should not be happen but", err.Error())
+	}
+	if count != 100 {
+		t.Error("row count should be 100 but", count)
+	}
+	stop := errors.New("stop")
+	if err = w.StreamRows("Sheet1", func(row *Row) error { return stop }); err != stop {
+		t.Error("error should be returned from callback.")
+	}
+	if err = w.StreamRows("NoSheet", func(row *Row) error { return nil }); err == nil {
+		t.Error("sheet should not be found.")
+	}
+}
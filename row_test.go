@@ -99,6 +99,21 @@ func TestGetCell(t *testing.T) {
 	}
 }
 
+func TestGetCellOrder(t *testing.T) {
+	row := &Row{row: &Tag{}}
+	for _, colNo := range []int{5, 2, 8, 1, 3} {
+		row.GetCell(colNo)
+	}
+	if c := row.GetCell(2); c != row.cells[1] {
+		t.Error("existing cell should be returned.")
+	}
+	for i, colNo := range []int{1, 2, 3, 5, 8} {
+		if row.cells[i].colNo != colNo {
+			t.Error("cell", i, "should be column", colNo, "but", row.cells[i].colNo)
+		}
+	}
+}
+
 func TestSetRowString(t *testing.T) {
 	f, _ := os.Create("temp/test.xml")
 	defer func() {
@@ -147,6 +162,22 @@ func TestSetRowNumber(t *testing.T) {
 	}
 }
 
+func TestSetRowBool(t *testing.T) {
+	tag := &Tag{}
+	tag.setAttr("r", "10")
+	row := NewRow(tag, nil, nil)
+	c := row.SetBool(true, 1)
+	if val, _ := c.cell.getAttr("t"); val != "b" {
+		t.Error("cell attribute should be b but", val)
+	}
+	if v, err := c.GetBool(); err != nil || !v {
+		t.Error("cell value should be true.")
+	}
+	if v, _ := row.SetBool(false, 2).GetBool(); v {
+		t.Error("cell value should be false.")
+	}
+}
+
 func TestSetRowDate(t *testing.T) {
 	tag := &Tag{}
 	tag.setAttr("r", "10")
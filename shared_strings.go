@@ -5,36 +5,57 @@ import (
 	"encoding/xml"
 	"errors"
 	"io"
-	"os"
-	"path/filepath"
 	"strings"
 )
 
 // SharedStrings 構造体
 type SharedStrings struct {
-	file        *os.File
-	tempFile    *os.File
+	file        File
+	tempFile    File
 	count       int
-	dir         string
+	storage     Storage
 	afterString string
 	buffer      *bytes.Buffer
+	indexFile   File
+	indexBuffer *bytes.Buffer
+	indexSize   int64
+	offsets     []int64
+	hashes      *hashIndex
+	cache       map[string]int
+	cacheSize   int
+	err         error
 }
 
+// maxStringCacheSize 重複確認用にメモリに保持する文字列の最大バイト数
+const maxStringCacheSize = 1 << 22
+
+const (
+	sharedStringsName      = "xl/sharedStrings.xml"
+	sharedStringsTempName  = "xl/__sharedStrings.xml"
+	sharedStringsIndexName = "xl/__sharedStringsIndex.dat"
+	sharedStringsHashName  = "xl/__sharedStringsHash.dat"
+)
+
 // OpenSharedStrings 新しいSharedString構造体を作成する
 func OpenSharedStrings(dir string) (*SharedStrings, error) {
-	var f *os.File
+	return openSharedStrings(newDirStorage(dir))
+}
+
+// openSharedStrings ストレージのsharedStrings.xmlを開く
+func openSharedStrings(storage Storage) (*SharedStrings, error) {
+	var f File
 	var err error
-	path := filepath.Join(dir, "xl", "sharedStrings.xml")
-	if !isFileExist(path) {
-		f, err = os.Create(path)
+	path := sharedStringsName
+	if !storage.Exists(path) {
+		f, err = storage.Create(path)
 		if err != nil {
 			return nil, err
 		}
 		f.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n")
 		f.WriteString(`<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"></sst>`)
-		f.Seek(0, os.SEEK_SET)
+		f.Seek(0, io.SeekStart)
 	} else {
-		f, err = os.Open(path)
+		f, err = storage.Open(path)
 		if err != nil {
 			return nil, err
 		}
@@ -46,7 +67,7 @@ func OpenSharedStrings(dir string) (*SharedStrings, error) {
 		return nil, err
 	}
 	f.Close()
-	ss := &SharedStrings{dir: filepath.Join(dir, "xl"), buffer: &bytes.Buffer{}}
+	ss := &SharedStrings{storage: storage, buffer: &bytes.Buffer{}}
 	ss.setStringCount(tag)
 	if ss.count == -1 {
 		return nil, errors.New("The sharedStrings.xml file is currupt.")
@@ -58,15 +79,25 @@ func OpenSharedStrings(dir string) (*SharedStrings, error) {
 	if len(strs) != 2 {
 		return nil, errors.New("The sharedStrings.xml file is currupt.")
 	}
-	ss.file, err = os.Create(path)
+	ss.file, err = storage.Create(path)
 	if err != nil {
 		return nil, err
 	}
-	ss.tempFile, err = os.Create(filepath.Join(dir, "xl", "__sharedStrings.xml"))
+	ss.tempFile, err = storage.Create(sharedStringsTempName)
 	if err != nil {
 		ss.file.Close()
 		return nil, err
 	}
+	ss.indexFile, err = storage.Create(sharedStringsIndexName)
+	if err != nil {
+		ss.file.Close()
+		ss.tempFile.Close()
+		return nil, err
+	}
+	ss.indexBuffer = &bytes.Buffer{}
+	ss.hashes = newHashIndex(storage, sharedStringsHashName, defaultHashIndexLimit)
+	ss.cache = map[string]int{}
+	ss.setIndex(tag)
 
 	ss.file.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n")
 	ss.file.WriteString(strs[0])
@@ -81,9 +112,10 @@ func (ss *SharedStrings) Close() error {
 	}
 	defer ss.tempFile.Close()
 	defer ss.file.Close()
+	defer ss.closeIndex()
 	var err error
 	io.Copy(ss.tempFile, ss.buffer)
-	ss.tempFile.Seek(0, os.SEEK_SET)
+	ss.tempFile.Seek(0, io.SeekStart)
 	if _, err = io.Copy(ss.file, ss.tempFile); err != nil {
 		return err
 	}
@@ -96,7 +128,7 @@ func (ss *SharedStrings) Close() error {
 	if err = ss.file.Close(); err != nil {
 		return err
 	}
-	os.Remove(filepath.Join(ss.dir, "__sharedStrings.xml"))
+	ss.storage.Remove(sharedStringsTempName)
 	ss.tempFile = nil
 	ss.file = nil
 	return nil
@@ -152,8 +184,12 @@ func escapeText(w io.Writer, s []byte) error {
 }
 
 // AddString 文字列データを追加する
+// 同じ文字列がすでに存在する場合はそのインデックスを返す
 // 戻り値はインデックス情報(0スタート)
 func (ss *SharedStrings) AddString(text string) int {
+	if index, ok := ss.lookup(text); ok {
+		return index
+	}
 	if len(text) != 0 && (text[0] == ' ' || text[len(text)-1] == ' ') {
 		ss.buffer.WriteString(`<si><t xml:space="preserve">`)
 	} else {
@@ -166,9 +202,191 @@ func (ss *SharedStrings) AddString(text string) int {
 		ss.buffer = &bytes.Buffer{}
 	}
 	ss.count++
+	ss.addIndex(text)
+	ss.register(text, ss.count-1)
 	return ss.count - 1
 }
 
+// lookup 重複する文字列のインデックスを検索する
+func (ss *SharedStrings) lookup(text string) (int, bool) {
+	if ss.hashes == nil {
+		return 0, false
+	}
+	if index, ok := ss.cache[text]; ok {
+		return index, true
+	}
+	index, ok := ss.hashes.get(hashString(text))
+	if !ok {
+		return 0, false
+	}
+	// ハッシュ値の衝突がありえるため文字列を比較する
+	if str, err := ss.GetString(index); err != nil || str != text {
+		return 0, false
+	}
+	return index, true
+}
+
+// Err get the error which stopped the detection of duplicated strings or the string index
+// Strings are still added without the detection after the error,
+// but GetString fails once the index is stopped.
+func (ss *SharedStrings) Err() error {
+	if ss == nil {
+		return nil
+	}
+	return ss.err
+}
+
+// register 重複確認用に文字列を登録する
+// 登録に失敗した場合は重複の確認をやめてエラーを記録する
+func (ss *SharedStrings) register(text string, index int) {
+	if ss.hashes == nil {
+		return
+	}
+	if ss.cacheSize+len(text) <= maxStringCacheSize {
+		if _, ok := ss.cache[text]; !ok {
+			ss.cache[text] = index
+			ss.cacheSize += len(text)
+		}
+	}
+	hash := hashString(text)
+	if _, ok := ss.hashes.get(hash); ok {
+		return
+	}
+	if err := ss.hashes.put(hash, index); err != nil {
+		ss.hashes.close()
+		ss.hashes = nil
+		ss.cache = nil
+		ss.err = err
+	}
+}
+
+// Count 文字列の数を取得する
+func (ss *SharedStrings) Count() int {
+	if ss == nil {
+		return 0
+	}
+	return ss.count
+}
+
+// GetString インデックス(0スタート)の文字列を取得する
+// リッチテキストはプレーンテキストとして取得する
+func (ss *SharedStrings) GetString(index int) (string, error) {
+	if ss != nil && ss.indexFile == nil && ss.err != nil {
+		return "", ss.err
+	}
+	if ss == nil || index < 0 || len(ss.offsets) <= index {
+		return "", errors.New("The shared string index is out of range.")
+	}
+	start := ss.offsets[index]
+	end := ss.indexSize
+	if index+1 < len(ss.offsets) {
+		end = ss.offsets[index+1]
+	}
+	if end > ss.indexSize-int64(ss.indexBuffer.Len()) {
+		if err := ss.flushIndex(); err != nil {
+			ss.failIndex(err)
+			return "", err
+		}
+	}
+	b := make([]byte, end-start)
+	if _, err := ss.indexFile.ReadAt(b, start); err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// addIndex 文字列を検索用のインデックスファイルに追加する
+func (ss *SharedStrings) addIndex(text string) {
+	if ss.indexFile == nil {
+		return
+	}
+	ss.offsets = append(ss.offsets, ss.indexSize)
+	ss.indexBuffer.WriteString(text)
+	ss.indexSize += int64(len(text))
+	if ss.indexBuffer.Len() > 1024 {
+		if err := ss.flushIndex(); err != nil {
+			ss.failIndex(err)
+		}
+	}
+}
+
+// flushIndex インデックスのバッファをファイルに書き込む
+func (ss *SharedStrings) flushIndex() error {
+	_, err := io.Copy(ss.indexFile, ss.indexBuffer)
+	return err
+}
+
+// failIndex インデックスへの書き込みに失敗した場合にエラーを記録してインデックスを閉じる
+func (ss *SharedStrings) failIndex(err error) {
+	if ss.err == nil {
+		ss.err = err
+	}
+	ss.closeIndex()
+}
+
+// closeIndex インデックスファイルを削除する
+func (ss *SharedStrings) closeIndex() {
+	if ss.indexFile == nil {
+		return
+	}
+	ss.indexFile.Close()
+	ss.storage.Remove(sharedStringsIndexName)
+	ss.indexFile = nil
+	ss.offsets = nil
+	ss.hashes.close()
+	ss.hashes = nil
+	ss.cache = nil
+}
+
+// setIndex 既存の文字列をインデックスに追加する
+func (ss *SharedStrings) setIndex(tag *Tag) {
+	for _, t := range tag.Children {
+		switch child := t.(type) {
+		case *Tag:
+			if child.Name.Local != "si" {
+				continue
+			}
+			text := plainText(child)
+			ss.addIndex(text)
+			if isPlainString(child) {
+				ss.register(text, len(ss.offsets)-1)
+			}
+		}
+	}
+}
+
+// isPlainString 書式のない文字列か確認する
+func isPlainString(si *Tag) bool {
+	count := 0
+	for _, child := range si.Children {
+		if tag, ok := child.(*Tag); ok {
+			if tag.Name.Local != "t" {
+				return false
+			}
+			count++
+		}
+	}
+	return count <= 1
+}
+
+// plainText <si>や<is>タグの文字列をプレーンテキストとして取得する
+// リッチテキストの<r>は連結し、ふりがなの<rPh>は除外する
+func plainText(tag *Tag) string {
+	var text string
+	for _, child := range tag.Children {
+		switch t := child.(type) {
+		case *Tag:
+			switch t.Name.Local {
+			case "t":
+				text += t.text()
+			case "r":
+				text += plainText(t)
+			}
+		}
+	}
+	return text
+}
+
 // setStringCount 文字列のカウントをセットする
 func (ss *SharedStrings) setStringCount(tag *Tag) {
 	if tag.Name.Local != "sst" {
@@ -3,9 +3,11 @@ package excl
 import (
 	"bytes"
 	"encoding/xml"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"testing"
 )
 
@@ -89,6 +91,73 @@ func TestAddString(t *testing.T) {
 	}
 }
 
+func TestAddStringDuplicate(t *testing.T) {
+	os.Mkdir("temp/xl", 0755)
+	defer os.RemoveAll("temp/xl")
+	f, _ := os.Create(filepath.Join("temp", "xl", "sharedStrings.xml"))
+	f.WriteString(`<sst><si><t>exist</t></si><si><r><rPr><b/></rPr><t>rich</t></r></si></sst>`)
+	f.Close()
+	ss, _ := OpenSharedStrings("temp")
+	if index := ss.AddString("exist"); index != 0 {
+		t.Error("index should be 0 but", index)
+	}
+	if index := ss.AddString("rich"); index != 2 {
+		t.Error("index should be 2 because rich text is not same as plain text but", index)
+	}
+	if index := ss.AddString("new"); index != 3 {
+		t.Error("index should be 3 but", index)
+	}
+	if index := ss.AddString("rich"); index != 2 {
+		t.Error("index should be 2 but", index)
+	}
+	ss.cache = map[string]int{}
+	if index := ss.AddString("new"); index != 3 {
+		t.Error("index should be 3 without cache but", index)
+	}
+	if ss.Count() != 4 {
+		t.Error("count should be 4 but", ss.Count())
+	}
+	ss.Close()
+	b, _ := ioutil.ReadFile(filepath.Join("temp", "xl", "sharedStrings.xml"))
+	if string(b) != "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n<sst><si><t>exist</t></si><si><r><rPr><b></b></rPr><t>rich</t></r></si><si><t>rich</t></si><si><t>new</t></si></sst>" {
+		t.Error(string(b))
+	}
+	if isFileExist(filepath.Join("temp", "xl", "__sharedStringsIndex.dat")) {
+		t.Error("__sharedStringsIndex.dat should be removed.")
+	}
+}
+
+func TestGetString(t *testing.T) {
+	os.Mkdir("temp/xl", 0755)
+	defer os.RemoveAll("temp/xl")
+	f, _ := os.Create(filepath.Join("temp", "xl", "sharedStrings.xml"))
+	f.WriteString(`<sst><si><t>plain</t></si>`)
+	f.WriteString(`<si><r><rPr><b/></rPr><t>rich</t></r><r><t xml:space="preserve"> text</t></r></si>`)
+	f.WriteString(`<si><t>漢字</t><rPh sb="0" eb="2"><t>カンジ</t></rPh><phoneticPr fontId="1"/></si></sst>`)
+	f.Close()
+	ss, _ := OpenSharedStrings("temp")
+	defer ss.Close()
+	if ss.Count() != 3 {
+		t.Error("count should be 3 but", ss.Count())
+	}
+	index := ss.AddString("added")
+	expects := []string{"plain", "rich text", "漢字", "added"}
+	for i, expect := range expects {
+		if str, err := ss.GetString(i); err != nil || str != expect {
+			t.Error("string should be", expect, "but", str, err)
+		}
+	}
+	if index != 3 {
+		t.Error("index should be 3 but", index)
+	}
+	if _, err := ss.GetString(4); err == nil {
+		t.Error("string should not be found.")
+	}
+	if _, err := ss.GetString(-1); err == nil {
+		t.Error("string should not be found.")
+	}
+}
+
 func TestEscapeText(t *testing.T) {
 	buf := new(bytes.Buffer)
 	escapeText(buf, []byte("\"'&<>\t\n\rあいう"))
@@ -113,3 +182,89 @@ func BenchmarkAddString(b *testing.B) {
 		}
 	}
 }
+
+// failCreateStorage 指定したパーツの作成に失敗するストレージ
+type failCreateStorage struct {
+	Storage
+	name string
+}
+
+func (s *failCreateStorage) Create(name string) (File, error) {
+	if name == s.name {
+		return nil, errors.New("disk full")
+	}
+	return s.Storage.Create(name)
+}
+
+func TestAddStringSpillError(t *testing.T) {
+	ss, err := openSharedStrings(&failCreateStorage{Storage: NewMemoryStorage(), name: sharedStringsHashName})
+	if err != nil {
+		t.Fatal("shared strings should be opened.", err.Error())
+	}
+	defer ss.Close()
+	ss.hashes.limit = 2
+	for i := 0; i < 3; i++ {
+		ss.AddString(strconv.Itoa(i))
+	}
+	if ss.Err() == nil {
+		t.Error("spill error should be recorded.")
+	}
+	if index := ss.AddString("0"); index != 3 {
+		t.Error("strings should be added without detection of duplicates but", index)
+	}
+	if str, err := ss.GetString(3); err != nil || str != "0" {
+		t.Error("string should be added.", str, err)
+	}
+}
+
+// failWriteStorage 指定したパーツへの書き込みに失敗するストレージ
+type failWriteStorage struct {
+	Storage
+	name string
+}
+
+type failWriteFile struct {
+	File
+}
+
+func (s *failWriteStorage) Create(name string) (File, error) {
+	f, err := s.Storage.Create(name)
+	if err != nil || name != s.name {
+		return f, err
+	}
+	return &failWriteFile{f}, nil
+}
+
+func (f *failWriteFile) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func TestAddStringIndexError(t *testing.T) {
+	ss, err := openSharedStrings(&failWriteStorage{Storage: NewMemoryStorage(), name: sharedStringsIndexName})
+	if err != nil {
+		t.Fatal("shared strings should be opened.", err.Error())
+	}
+	defer ss.Close()
+	text := string(bytes.Repeat([]byte("a"), 1025))
+	if index := ss.AddString(text); index != 0 {
+		t.Error("string should be added but", index)
+	}
+	if ss.Err() == nil {
+		t.Error("index error should be recorded.")
+	}
+	if _, err := ss.GetString(0); err == nil || err != ss.Err() {
+		t.Error("index error should be returned.", err)
+	}
+}
+
+func TestSaveIndexError(t *testing.T) {
+	workbook, err := Create(WithStorage(&failWriteStorage{Storage: NewMemoryStorage(), name: sharedStringsIndexName}))
+	if err != nil {
+		t.Fatal("workbook should be created.", err.Error())
+	}
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	sheet.GetRow(1).SetString(string(bytes.Repeat([]byte("a"), 1025)), 1)
+	if err := workbook.Close(); err == nil {
+		t.Error("index error should be returned.")
+	}
+}
@@ -5,8 +5,7 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"os"
-	"path/filepath"
+	"path"
 	"strconv"
 	"strings"
 )
@@ -21,14 +20,35 @@ type Sheet struct {
 	sheetView     *Tag
 	cols          *Tag
 	sheetData     *Tag
-	tempFile      *os.File
-	afterString   string
+	tempFile      File
+	headerOutput  bool
+	outputRowNo   int
+	mergedRanges  []cellRange
 	sharedStrings *SharedStrings
 	sheetPath     string
 	tempSheetPath string
 	colInfos      colInfos
 	maxRow        int
 	target        string
+	storage       Storage
+	workbook      *Workbook
+	rels          *SheetRels
+	comments      *sheetComments
+	drawing       *sheetDrawing
+	// createdParts このライブラリで作成したパーツ。シートを閉じても保持する
+	createdParts map[string]bool
+}
+
+// worksheetOrder worksheetの子タグの順序
+var worksheetOrder = []string{
+	"sheetPr", "dimension", "sheetViews", "sheetFormatPr", "cols", "sheetData",
+	"sheetCalcPr", "sheetProtection", "protectedRanges", "scenarios", "autoFilter",
+	"sortState", "dataConsolidate", "customSheetViews", "mergeCells", "phoneticPr",
+	"conditionalFormatting", "dataValidations", "hyperlinks", "printOptions",
+	"pageMargins", "pageSetup", "headerFooter", "rowBreaks", "colBreaks",
+	"customProperties", "cellWatches", "ignoredErrors", "smartTags", "drawing",
+	"legacyDrawing", "legacyDrawingHF", "picture", "oleObjects", "controls",
+	"webPublishItems", "tableParts", "extLst",
 }
 
 // SheetXML sheet.xml information
@@ -65,7 +85,12 @@ func newSheet(name string, index int, rid string, target string) *Sheet {
 
 // Create create new sheet
 func (sheet *Sheet) Create(dir string) error {
-	f, err := os.Create(filepath.Join(dir, "xl", sheet.target))
+	return sheet.create(newDirStorage(dir))
+}
+
+// create create new sheet in the storage
+func (sheet *Sheet) create(storage Storage) error {
+	f, err := storage.Create(sheet.partName())
 	if err != nil {
 		return err
 	}
@@ -75,16 +100,21 @@ func (sheet *Sheet) Create(dir string) error {
 	f.WriteString("<sheetData></sheetData>")
 	f.WriteString("</worksheet>")
 	f.Close()
-	sheet.Open(dir)
-	return nil
+	return sheet.open(storage)
 }
 
 // Open open sheet.xml in directory
 func (sheet *Sheet) Open(dir string) error {
+	return sheet.open(newDirStorage(dir))
+}
+
+// open open sheet.xml in the storage
+func (sheet *Sheet) open(storage Storage) error {
 	var err error
-	sheet.sheetPath = filepath.Join(dir, "xl", sheet.target)
-	sheet.tempSheetPath = filepath.Join(dir, "xl", sheet.target+".tmp")
-	f, err := os.Open(sheet.sheetPath)
+	sheet.storage = storage
+	sheet.sheetPath = sheet.partName()
+	sheet.tempSheetPath = sheet.sheetPath + ".tmp"
+	f, err := storage.Open(sheet.sheetPath)
 	if err != nil {
 		return err
 	}
@@ -98,7 +128,7 @@ func (sheet *Sheet) Open(dir string) error {
 	}
 	sheet.worksheet = tag
 	sheet.setSeparatePoint()
-	if sheet.tempFile, err = os.Create(sheet.tempSheetPath); err != nil {
+	if sheet.tempFile, err = storage.Create(sheet.tempSheetPath); err != nil {
 		return err
 	}
 	sheet.opened = true
@@ -111,23 +141,45 @@ func (sheet *Sheet) Close() error {
 	if sheet == nil || sheet.opened == false {
 		return nil
 	}
+	if sheet.tempFile == nil {
+		return errors.New("The temporary file of the sheet [" + sheet.xml.Name + "] is not created.")
+	}
 	sheet.OutputAll()
-	if _, err = sheet.tempFile.WriteString(sheet.afterString); err != nil {
+	if err = sheet.closeComments(); err != nil {
+		return err
+	}
+	if err = sheet.closeDrawing(); err != nil {
+		return err
+	}
+	if err = sheet.outputLast(); err != nil {
 		return err
 	}
 	sheet.tempFile.Close()
-	os.Remove(sheet.sheetPath)
-	if err := os.Rename(sheet.tempSheetPath, sheet.sheetPath); err != nil {
+	if err := sheet.storage.Rename(sheet.tempSheetPath, sheet.sheetPath); err != nil {
+		return err
+	}
+	if err := sheet.rels.Close(); err != nil {
 		return err
 	}
 	sheet.opened = false
+	sheet.headerOutput = false
+	sheet.outputRowNo = 0
+	sheet.mergedRanges = nil
 	sheet.worksheet = nil
 	sheet.sheetView = nil
 	sheet.sheetData = nil
 	sheet.tempFile = nil
+	sheet.rels = nil
+	sheet.comments = nil
+	sheet.drawing = nil
 	return nil
 }
 
+// partName get the name of sheet.xml in the storage
+func (sheet *Sheet) partName() string {
+	return path.Join("xl", sheet.target)
+}
+
 func (sheet *Sheet) setData(sheetTag *Tag) error {
 	if sheetTag.Name.Local != "worksheet" {
 		return errors.New("The file [" + sheet.sheetPath + "] is currupt.")
@@ -145,6 +197,7 @@ func (sheet *Sheet) setData(sheetTag *Tag) error {
 								return errors.New("The file [" + sheet.sheetPath + "] is currupt.")
 							}
 							newRow.colInfos = sheet.colInfos
+							newRow.sheet = sheet
 							sheet.Rows = append(sheet.Rows, newRow)
 							sheet.maxRow = newRow.rowID
 						}
@@ -152,6 +205,8 @@ func (sheet *Sheet) setData(sheetTag *Tag) error {
 				}
 				sheet.sheetData = tag
 				break
+			} else if tag.Name.Local == "mergeCells" {
+				sheet.mergedRanges = getMergedRanges(tag)
 			} else if tag.Name.Local == "cols" {
 				sheet.cols = tag
 				sheet.colInfos = getColInfos(tag)
@@ -214,18 +269,20 @@ func (sheet *Sheet) CreateRows(from int, to int) []*Row {
 			Name: xml.Name{Local: "row"},
 			Attr: attr,
 		}
-		rows[i] = &Row{rowID: i + 1, row: tag, sharedStrings: sheet.sharedStrings}
+		rows[i] = &Row{rowID: i + 1, row: tag, sharedStrings: sheet.sharedStrings, sheet: sheet}
 	}
 	return sheet.Rows
 }
 
 // GetRow get row(from 1)
 func (sheet *Sheet) GetRow(rowNo int) *Row {
-	for _, row := range sheet.Rows {
+	// 行は末尾に追加されることが多いため後ろから探す
+	for i := len(sheet.Rows) - 1; i >= 0; i-- {
+		row := sheet.Rows[i]
 		if row.rowID == rowNo {
 			return row
 		}
-		if row.rowID > rowNo {
+		if row.rowID < rowNo {
 			break
 		}
 	}
@@ -242,6 +299,12 @@ func (sheet *Sheet) GetRow(rowNo int) *Row {
 	}
 	row := NewRow(tag, sheet.sharedStrings, sheet.Styles)
 	row.colInfos = sheet.colInfos
+	row.sheet = sheet
+	if n := len(sheet.Rows); n == 0 || sheet.Rows[n-1].rowID < rowNo {
+		// 最後の行より後ろの場合は末尾に追加する
+		sheet.Rows = append(sheet.Rows, row)
+		return row
+	}
 	added := false
 	rows := make([]*Row, len(sheet.Rows)+1)
 	for i := 0; i < len(sheet.Rows); i++ {
@@ -273,29 +336,45 @@ func (sheet *Sheet) ShowGridlines(show bool) {
 	}
 }
 
-func (sheet *Sheet) outputFirst() {
+// splitWorksheet worksheetをcolsとsheetDataの位置で分割した文字列を取得する
+func (sheet *Sheet) splitWorksheet() []string {
 	var b bytes.Buffer
 	xml.NewEncoder(&b).Encode(sheet.worksheet)
-	strs := strings.Split(b.String(), "<separate_tag></separate_tag>")
+	return strings.Split(b.String(), "<separate_tag></separate_tag>")
+}
+
+// outputFirst output tags before rows
+func (sheet *Sheet) outputFirst() {
+	if sheet.headerOutput {
+		return
+	}
+	strs := sheet.splitWorksheet()
 	sheet.tempFile.WriteString(strs[0])
 	if len(sheet.colInfos) != 0 {
 		xml.NewEncoder(sheet.tempFile).Encode(sheet.colInfos)
 	}
 	sheet.tempFile.WriteString(strs[1])
-	sheet.afterString = strs[2]
-	sheet.worksheet = nil
+	sheet.headerOutput = true
+}
+
+// outputLast output tags after rows
+// Tags after sheetData such as mergeCells can be changed until the sheet is closed.
+func (sheet *Sheet) outputLast() error {
+	strs := sheet.splitWorksheet()
+	_, err := sheet.tempFile.WriteString(strs[2])
+	return err
 }
 
 // OutputAll output all rows
 func (sheet *Sheet) OutputAll() {
-	if sheet.worksheet != nil {
-		sheet.outputFirst()
-	}
+	sheet.outputFirst()
 	var buffer bytes.Buffer
 	for i, row := range sheet.Rows {
 		if row != nil {
+			sheet.clearMergedCells(row)
 			row.resetStyleIndex()
 			xml.NewEncoder(&buffer).Encode(sheet.Rows[i])
+			sheet.outputRowNo = row.rowID
 			if i > 0 && i%100 == 0 {
 				sheet.tempFile.Write(buffer.Bytes())
 				buffer.Reset()
@@ -309,9 +388,7 @@ func (sheet *Sheet) OutputAll() {
 // OutputThroughRowNo output through to rowno
 func (sheet *Sheet) OutputThroughRowNo(rowNo int) {
 	var i int
-	if sheet.worksheet != nil {
-		sheet.outputFirst()
-	}
+	sheet.outputFirst()
 	var buffer bytes.Buffer
 	for i = 0; i < len(sheet.Rows); i++ {
 		if sheet.Rows[i] == nil {
@@ -320,15 +397,21 @@ func (sheet *Sheet) OutputThroughRowNo(rowNo int) {
 		if rowNo < sheet.Rows[i].rowID {
 			break
 		}
+		sheet.clearMergedCells(sheet.Rows[i])
 		sheet.Rows[i].resetStyleIndex()
 		xml.NewEncoder(&buffer).Encode(sheet.Rows[i])
+		sheet.outputRowNo = sheet.Rows[i].rowID
 		if i > 0 && i%100 == 0 {
 			sheet.tempFile.Write(buffer.Bytes())
 			buffer.Reset()
 		}
 	}
 	sheet.tempFile.Write(buffer.Bytes())
-	sheet.tempFile.Sync()
+	if f, ok := sheet.tempFile.(interface {
+		Sync() error
+	}); ok {
+		f.Sync()
+	}
 
 	sheet.Rows = sheet.Rows[i:]
 }
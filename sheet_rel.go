@@ -0,0 +1,150 @@
+package excl
+
+import (
+	"encoding/xml"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+const (
+	relsNamespace     = "http://schemas.openxmlformats.org/package/2006/relationships"
+	relsContentType   = "application/vnd.openxmlformats-package.relationships+xml"
+	relTypeHyperlink  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink"
+	relTypeComments   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/comments"
+	relTypeVMLDrawing = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/vmlDrawing"
+)
+
+// SheetRels sheetN.xml.relsの情報をもつ構造体
+type SheetRels struct {
+	rels    *Relationships
+	storage Storage
+	path    string
+}
+
+// relsPath パーツのrelsファイルのパスを取得する
+func relsPath(partName string) string {
+	return path.Join(path.Dir(partName), "_rels", path.Base(partName)+".rels")
+}
+
+// resolveTarget パーツからの相対パスをストレージ内のパスにする
+func resolveTarget(partName string, target string) string {
+	if strings.HasPrefix(target, "/") {
+		return target[1:]
+	}
+	return path.Join(path.Dir(partName), target)
+}
+
+// openSheetRels シートのrelsファイルを開く。ない場合は空の状態で作成する
+func openSheetRels(storage Storage, sheetPath string) (*SheetRels, error) {
+	sr := &SheetRels{
+		rels:    &Relationships{Xmlns: relsNamespace},
+		storage: storage,
+		path:    relsPath(sheetPath),
+	}
+	if !storage.Exists(sr.path) {
+		return sr, nil
+	}
+	f, err := storage.Open(sr.path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	if err = xml.Unmarshal(data, sr.rels); err != nil {
+		return nil, err
+	}
+	return sr, nil
+}
+
+// Close relsファイルに書き出す。リレーションがない場合はファイルを削除する
+func (sr *SheetRels) Close() error {
+	if sr == nil {
+		return nil
+	}
+	if len(sr.rels.Rels) == 0 {
+		if sr.storage.Exists(sr.path) {
+			return sr.storage.Remove(sr.path)
+		}
+		return nil
+	}
+	f, err := sr.storage.Create(sr.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := xml.Marshal(sr.rels)
+	if err != nil {
+		return err
+	}
+	f.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n")
+	f.Write(data)
+	return f.Close()
+}
+
+// addRel リレーションを追加してIDを返す。外部のリンクの場合はexternalをtrueにする
+func (sr *SheetRels) addRel(relType string, target string, external bool) string {
+	rel := relationship{
+		XMLName: xml.Name{Local: "Relationship"},
+		ID:      sr.rels.nextID(),
+		Type:    relType,
+		Target:  target,
+	}
+	if external {
+		rel.Mode = "External"
+	}
+	sr.rels.Rels = append(sr.rels.Rels, rel)
+	return rel.ID
+}
+
+// getRel IDのリレーションを取得する。ない場合はnilを返す
+func (sr *SheetRels) getRel(rid string) *relationship {
+	for i := range sr.rels.Rels {
+		if sr.rels.Rels[i].ID == rid {
+			return &sr.rels.Rels[i]
+		}
+	}
+	return nil
+}
+
+// findRel 種類が一致する最初のリレーションを取得する。ない場合はnilを返す
+func (sr *SheetRels) findRel(relType string) *relationship {
+	for i := range sr.rels.Rels {
+		if sr.rels.Rels[i].Type == relType {
+			return &sr.rels.Rels[i]
+		}
+	}
+	return nil
+}
+
+// removeRel IDのリレーションを削除する
+func (sr *SheetRels) removeRel(rid string) {
+	for i, rel := range sr.rels.Rels {
+		if rel.ID == rid {
+			sr.rels.Rels = append(sr.rels.Rels[:i], sr.rels.Rels[i+1:]...)
+			return
+		}
+	}
+}
+
+// getRels シートのリレーションを取得する。開いていない場合は開く
+func (sheet *Sheet) getRels() (*SheetRels, error) {
+	if sheet.rels != nil {
+		return sheet.rels, nil
+	}
+	rels, err := openSheetRels(sheet.storage, sheet.sheetPath)
+	if err != nil {
+		return nil, err
+	}
+	if sheet.workbook != nil {
+		sheet.workbook.types.addDefault("rels", relsContentType)
+	}
+	if _, err := sheet.worksheet.getAttr("xmlns:r"); err != nil {
+		sheet.worksheet.setAttr("xmlns:r", "http://schemas.openxmlformats.org/officeDocument/2006/relationships")
+	}
+	sheet.rels = rels
+	return rels, nil
+}
@@ -2,6 +2,7 @@ package excl
 
 import (
 	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -90,6 +91,29 @@ func TestGetRow(t *testing.T) {
 	}
 }
 
+func TestGetRowOutOfOrder(t *testing.T) {
+	sheet := &Sheet{}
+	created := map[int]*Row{}
+	for _, no := range []int{5, 2, 8, 1, 3, 10, 7} {
+		created[no] = sheet.GetRow(no)
+	}
+	ids := make([]int, len(sheet.Rows))
+	for i, row := range sheet.Rows {
+		ids[i] = row.rowID
+	}
+	if fmt.Sprint(ids) != "[1 2 3 5 7 8 10]" {
+		t.Error("rows should be sorted but", ids)
+	}
+	for _, no := range []int{10, 1, 5, 8, 2} {
+		if sheet.GetRow(no) != created[no] {
+			t.Error("existing row should be returned.", no)
+		}
+	}
+	if len(sheet.Rows) != 7 {
+		t.Error("rows should not be added.", len(sheet.Rows))
+	}
+}
+
 func TestShowGridlines(t *testing.T) {
 	os.MkdirAll("temp/xl/worksheets", 0755)
 	defer os.RemoveAll("temp/xl")
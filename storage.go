@@ -0,0 +1,308 @@
+package excl
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// File is a part of a workbook stored in a Storage
+type File interface {
+	io.Reader
+	io.Writer
+	io.StringWriter
+	io.ReaderAt
+	io.WriterAt
+	io.Seeker
+	io.Closer
+}
+
+// Storage keeps the parts of a workbook while it is edited
+// Names are slash separated paths in the package such as "xl/workbook.xml".
+type Storage interface {
+	// Open open the part for reading
+	Open(name string) (File, error)
+	// Create create or truncate the part for reading and writing
+	Create(name string) (File, error)
+	// Remove remove the part
+	Remove(name string) error
+	// Rename rename the part. The new part is replaced if it exists.
+	Rename(oldname, newname string) error
+	// Exists check whether the part exists
+	Exists(name string) bool
+	// List get the names of all parts in sorted order
+	List() ([]string, error)
+	// Close release all parts. It is called when the workbook is closed.
+	Close() error
+}
+
+// Option is an option for Create and Open
+type Option func(*options)
+
+type options struct {
+	storage Storage
+}
+
+// WithStorage keep the parts of the workbook in the storage instead of a temporary directory
+func WithStorage(storage Storage) Option {
+	return func(opts *options) {
+		opts.storage = storage
+	}
+}
+
+// newOptions apply options
+func newOptions(opts []Option) (*options, error) {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.storage == nil {
+		storage, err := NewDirStorage("")
+		if err != nil {
+			return nil, err
+		}
+		o.storage = storage
+	}
+	return o, nil
+}
+
+// dirStorage keeps parts as files under the directory
+type dirStorage struct {
+	dir string
+}
+
+// NewDirStorage create a storage which keeps parts in a new directory under parent
+// The default temporary directory is used when parent is empty.
+// The directory is removed when the storage is closed.
+func NewDirStorage(parent string) (Storage, error) {
+	dir, err := ioutil.TempDir(parent, "excl"+strings.Replace(time.Now().Format("20060102030405.000"), ".", "", 1))
+	if err != nil {
+		return nil, err
+	}
+	return newDirStorage(dir), nil
+}
+
+// newDirStorage create a storage for the existing directory
+func newDirStorage(dir string) *dirStorage {
+	return &dirStorage{dir: dir}
+}
+
+func (s *dirStorage) path(name string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(path.Clean("/"+name)))
+}
+
+func (s *dirStorage) Open(name string) (File, error) {
+	return os.Open(s.path(name))
+}
+
+func (s *dirStorage) Create(name string) (File, error) {
+	if !isDirExist(s.dir) {
+		return nil, errors.New("The directory [" + s.dir + "] does not exist.")
+	}
+	p := s.path(name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, err
+	}
+	return os.Create(p)
+}
+
+func (s *dirStorage) Remove(name string) error {
+	return os.Remove(s.path(name))
+}
+
+func (s *dirStorage) Rename(oldname, newname string) error {
+	os.Remove(s.path(newname))
+	return os.Rename(s.path(oldname), s.path(newname))
+}
+
+func (s *dirStorage) Exists(name string) bool {
+	return isFileExist(s.path(name))
+}
+
+func (s *dirStorage) List() ([]string, error) {
+	var names []string
+	err := filepath.Walk(s.dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		names = append(names, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *dirStorage) Close() error {
+	return os.RemoveAll(s.dir)
+}
+
+// memoryStorage keeps parts in memory
+type memoryStorage struct {
+	mu    sync.Mutex
+	parts map[string]*memoryData
+}
+
+type memoryData struct {
+	b []byte
+}
+
+// memoryFile is a handle of a part in memoryStorage
+type memoryFile struct {
+	data *memoryData
+	pos  int64
+}
+
+// NewMemoryStorage create a storage which keeps parts in memory
+func NewMemoryStorage() Storage {
+	return &memoryStorage{parts: map[string]*memoryData{}}
+}
+
+func (s *memoryStorage) Open(name string) (File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.parts[path.Clean(name)]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return &memoryFile{data: data}, nil
+}
+
+func (s *memoryStorage) Create(name string) (File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data := &memoryData{}
+	s.parts[path.Clean(name)] = data
+	return &memoryFile{data: data}, nil
+}
+
+func (s *memoryStorage) Remove(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.parts[path.Clean(name)]; !ok {
+		return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+	}
+	delete(s.parts, path.Clean(name))
+	return nil
+}
+
+func (s *memoryStorage) Rename(oldname, newname string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.parts[path.Clean(oldname)]
+	if !ok {
+		return &os.PathError{Op: "rename", Path: oldname, Err: os.ErrNotExist}
+	}
+	delete(s.parts, path.Clean(oldname))
+	s.parts[path.Clean(newname)] = data
+	return nil
+}
+
+func (s *memoryStorage) Exists(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.parts[path.Clean(name)]
+	return ok
+}
+
+func (s *memoryStorage) List() ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	names := make([]string, 0, len(s.parts))
+	for name := range s.parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *memoryStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.parts = map[string]*memoryData{}
+	return nil
+}
+
+func (f *memoryFile) Read(b []byte) (int, error) {
+	n, err := f.ReadAt(b, f.pos)
+	f.pos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
+}
+
+func (f *memoryFile) ReadAt(b []byte, off int64) (int, error) {
+	if off >= int64(len(f.data.b)) {
+		return 0, io.EOF
+	}
+	n := copy(b, f.data.b[off:])
+	if n < len(b) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memoryFile) Write(b []byte) (int, error) {
+	n, err := f.WriteAt(b, f.pos)
+	f.pos += int64(n)
+	return n, err
+}
+
+func (f *memoryFile) WriteString(s string) (int, error) {
+	return f.Write([]byte(s))
+}
+
+func (f *memoryFile) WriteAt(b []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("negative offset")
+	}
+	end := off + int64(len(b))
+	if end > int64(len(f.data.b)) {
+		if end > int64(cap(f.data.b)) {
+			buf := make([]byte, end, end*2)
+			copy(buf, f.data.b)
+			f.data.b = buf
+		} else {
+			f.data.b = f.data.b[:end]
+		}
+	}
+	return copy(f.data.b[off:], b), nil
+}
+
+func (f *memoryFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.pos
+	case io.SeekEnd:
+		offset += int64(len(f.data.b))
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	f.pos = offset
+	return offset, nil
+}
+
+func (f *memoryFile) Close() error {
+	return nil
+}
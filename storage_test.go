@@ -0,0 +1,107 @@
+package excl
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func testStorage(t *testing.T, storage Storage) {
+	if _, err := storage.Open("xl/nothing.xml"); err == nil {
+		t.Error("part should not be opened.")
+	}
+	f, err := storage.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		t.Fatal("part should be created.", err.Error())
+	}
+	f.WriteString("hello world")
+	f.WriteAt([]byte("HELLO"), 0)
+	f.Seek(0, io.SeekStart)
+	b, _ := ioutil.ReadAll(f)
+	if string(b) != "HELLO world" {
+		t.Error("part should be [HELLO world] but", string(b))
+	}
+	f.Close()
+	if !storage.Exists("xl/worksheets/sheet1.xml") {
+		t.Error("part should exist.")
+	}
+	f, _ = storage.Create("[Content_Types].xml")
+	f.Close()
+	if err = storage.Rename("xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"); err != nil {
+		t.Error("part should be renamed.", err.Error())
+	}
+	names, _ := storage.List()
+	if len(names) != 2 || names[0] != "[Content_Types].xml" || names[1] != "xl/worksheets/sheet2.xml" {
+		t.Error("part names are invalid.", names)
+	}
+	f, _ = storage.Open("xl/worksheets/sheet2.xml")
+	b, _ = ioutil.ReadAll(f)
+	f.Close()
+	if string(b) != "HELLO world" {
+		t.Error("renamed part should be [HELLO world] but", string(b))
+	}
+	if err = storage.Remove("xl/worksheets/sheet2.xml"); err != nil {
+		t.Error("part should be removed.", err.Error())
+	}
+	if storage.Exists("xl/worksheets/sheet2.xml") {
+		t.Error("part should not exist.")
+	}
+	storage.Close()
+}
+
+func TestDirStorage(t *testing.T) {
+	storage, err := NewDirStorage("temp")
+	if err != nil {
+		t.Fatal("storage should be created.", err.Error())
+	}
+	dir := storage.(*dirStorage).dir
+	testStorage(t, storage)
+	if isDirExist(dir) {
+		os.RemoveAll(dir)
+		t.Error("directory should be removed.")
+	}
+	if _, err = newDirStorage("temp/nopath").Create("a.xml"); err == nil {
+		t.Error("part should not be created in the directory which does not exist.")
+	}
+}
+
+func TestMemoryStorage(t *testing.T) {
+	testStorage(t, NewMemoryStorage())
+}
+
+func TestWorkbookMemoryStorage(t *testing.T) {
+	workbook, err := Open("temp/test.xlsx", WithStorage(NewMemoryStorage()))
+	if err != nil {
+		t.Fatal("workbook should be opened.", err.Error())
+	}
+	if workbook.TempPath != "" {
+		t.Error("temp directory should not be used.", workbook.TempPath)
+	}
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	sheet.GetRow(1).SetString("memory", 1)
+	sheet.Close()
+	defer os.Remove("temp/memory.xlsx")
+	if err = workbook.Save("temp/memory.xlsx"); err != nil {
+		t.Fatal("workbook should be saved.", err.Error())
+	}
+
+	workbook, err = Create(WithStorage(NewMemoryStorage()))
+	if err != nil {
+		t.Fatal("workbook should be created.", err.Error())
+	}
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	sheet.GetRow(1).SetNumber("1", 1)
+	sheet.Close()
+	workbook.Save("temp/memory.xlsx")
+
+	workbook, err = Open("temp/memory.xlsx", WithStorage(NewMemoryStorage()))
+	if err != nil {
+		t.Fatal("workbook should be opened.", err.Error())
+	}
+	defer workbook.Close()
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	if v, _ := sheet.GetRow(1).GetCell(1).GetNumber(); v != 1 {
+		t.Error("value should be 1 but", v)
+	}
+}
@@ -0,0 +1,455 @@
+package excl
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// structField excl タグから読み取った構造体フィールドの情報
+type structField struct {
+	index  []int
+	name   string
+	colNo  int
+	hasCol bool
+	numFmt string
+	width  float64
+	typ    reflect.Type
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+var columnPattern = regexp.MustCompile(`^[A-Z]+$`)
+
+// structTagKeys excl タグで使えるキー
+var structTagKeys = []string{"name", "col", "numfmt", "width"}
+
+// parseStructTag excl:"name=Amount,col=C,numfmt=#,##0.00,width=12" を解析する
+// numfmt の値にはカンマを含められるため、既知のキーで始まらない部分は直前の値に連結する
+func parseStructTag(tag string) (map[string]string, error) {
+	values := map[string]string{}
+	if tag == "" {
+		return values, nil
+	}
+	key := ""
+	for _, part := range strings.Split(tag, ",") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) == 2 && IsExistString(structTagKeys, strings.TrimSpace(kv[0])) {
+			key = strings.TrimSpace(kv[0])
+			values[key] = kv[1]
+			continue
+		}
+		if key == "" {
+			return nil, errors.New("The excl tag [" + tag + "] is invalid.")
+		}
+		values[key] += "," + part
+	}
+	return values, nil
+}
+
+// parseColumn "C"や"3"から列番号を取得する
+func parseColumn(col string) int {
+	col = strings.ToUpper(strings.TrimSpace(col))
+	if n, err := strconv.Atoi(col); err == nil {
+		return n
+	}
+	if !columnPattern.MatchString(col) {
+		return 0
+	}
+	return ColNumPosition(col)
+}
+
+// isSupportedType セルに書き込める型か確認する
+func isSupportedType(typ reflect.Type) bool {
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == timeType {
+		return true
+	}
+	switch typ.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// getStructFields 構造体のフィールド情報を取得する
+// col が指定されていないフィールドは直前のフィールドの次の列になる
+func getStructFields(typ reflect.Type) ([]structField, error) {
+	var fields []structField
+	colNo := 0
+	used := map[int]bool{}
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+		tag := f.Tag.Get("excl")
+		if tag == "-" {
+			continue
+		}
+		values, err := parseStructTag(tag)
+		if err != nil {
+			return nil, err
+		}
+		if !isSupportedType(f.Type) {
+			return nil, errors.New("The type of field [" + f.Name + "] is not supported.")
+		}
+		field := structField{index: f.Index, name: f.Name, numFmt: values["numfmt"], typ: f.Type}
+		if name, ok := values["name"]; ok {
+			field.name = name
+		}
+		if col, ok := values["col"]; ok {
+			if colNo = parseColumn(col); colNo <= 0 {
+				return nil, errors.New("The column [" + col + "] of field [" + f.Name + "] is invalid.")
+			}
+			field.hasCol = true
+		} else {
+			colNo++
+		}
+		if used[colNo] {
+			return nil, errors.New("The column of field [" + f.Name + "] is already used.")
+		}
+		used[colNo] = true
+		field.colNo = colNo
+		if width, ok := values["width"]; ok {
+			if field.width, err = strconv.ParseFloat(width, 64); err != nil {
+				return nil, errors.New("The width [" + width + "] of field [" + f.Name + "] is invalid.")
+			}
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// Report ReadStructsの読み込み結果
+type Report struct {
+	// Rows 読み込んだデータ行の数
+	Rows int
+	// Errors 値を変換できなかったセルの一覧
+	Errors []*CellError
+	// MissingHeaders ヘッダー行に見つからなかった列名
+	MissingHeaders []string
+}
+
+// CellError セルの値を構造体のフィールドに変換できなかったエラー
+type CellError struct {
+	// Ref セルの位置(例: C17)
+	Ref   string
+	Row   int
+	Col   int
+	Field string
+	Err   error
+}
+
+// Error "C17: not a date" の形式でエラーを返す
+func (e *CellError) Error() string {
+	return e.Ref + ": " + e.Err.Error()
+}
+
+var (
+	errNotNumber  = errors.New("not a number")
+	errNotInteger = errors.New("not an integer")
+	errNotBool    = errors.New("not a boolean")
+	errNotDate    = errors.New("not a date")
+	errOutOfRange = errors.New("out of range")
+)
+
+// structSliceElem スライスの要素の構造体の型を取得する
+func structSliceElem(typ reflect.Type) (reflect.Type, error) {
+	if typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+		return nil, errors.New("The value should be a slice of structs.")
+	}
+	elem := typ.Elem()
+	if elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return nil, errors.New("The value should be a slice of structs.")
+	}
+	return elem, nil
+}
+
+// WriteStructs 構造体のスライスをstartRow行目のヘッダーとその下の行に書き込む
+// 列名、列、数値フォーマット、列幅は excl:"name=Amount,col=C,numfmt=#,##0.00,width=12" で指定する
+// NaNや無限大の値はExcelで扱えないためエラーになる
+func (sheet *Sheet) WriteStructs(startRow int, slice interface{}) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	if startRow <= 0 {
+		return errors.New("The row number should be greater than 0.")
+	}
+	value := reflect.ValueOf(slice)
+	elem, err := structSliceElem(value.Type())
+	if err != nil {
+		return err
+	}
+	fields, err := getStructFields(elem)
+	if err != nil {
+		return err
+	}
+	numFmtIDs := make([]int, len(fields))
+	header := sheet.GetRow(startRow)
+	for i, field := range fields {
+		header.SetString(field.name, field.colNo)
+		if field.numFmt != "" {
+			numFmtIDs[i] = sheet.Styles.SetNumFmt(field.numFmt)
+		}
+		if field.width > 0 {
+			sheet.SetColWidth(field.width, field.colNo)
+		}
+	}
+	for i := 0; i < value.Len(); i++ {
+		v := value.Index(i)
+		if v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				continue
+			}
+			v = v.Elem()
+		}
+		rowNo := startRow + i + 1
+		for _, field := range fields {
+			if !isFiniteValue(v.FieldByIndex(field.index)) {
+				return errors.New("The field [" + field.name + "] of the row [" + strconv.Itoa(rowNo) + "] is not a finite number.")
+			}
+		}
+		row := sheet.GetRow(rowNo)
+		for j, field := range fields {
+			cell := setStructValue(row, field.colNo, v.FieldByIndex(field.index))
+			if cell != nil && numFmtIDs[j] > 0 {
+				cell.SetStyle(&Style{NumFmtID: numFmtIDs[j]})
+			}
+		}
+	}
+	return nil
+}
+
+// isFiniteValue 浮動小数点数のフィールドがNaNや無限大でないか確認する
+func isFiniteValue(v reflect.Value) bool {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return true
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Float32 && v.Kind() != reflect.Float64 {
+		return true
+	}
+	f := v.Float()
+	return !math.IsNaN(f) && !math.IsInf(f, 0)
+}
+
+// setStructValue フィールドの型に合わせてセルに値を書き込む
+func setStructValue(row *Row, colNo int, v reflect.Value) *Cell {
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Type() == timeType {
+		return row.SetDate(v.Interface().(time.Time), colNo)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return row.SetString(v.String(), colNo)
+	case reflect.Bool:
+		return row.SetBool(v.Bool(), colNo)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return row.SetNumber(v.Int(), colNo)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return row.SetNumber(strconv.FormatUint(v.Uint(), 10), colNo)
+	case reflect.Float32, reflect.Float64:
+		return row.SetNumber(strconv.FormatFloat(v.Float(), 'f', -1, v.Type().Bits()), colNo)
+	}
+	return nil
+}
+
+// ReadStructs headerRow行目をヘッダーとしてそれ以降の行を構造体のスライスに読み込む
+// 列はexclタグのcol、またはヘッダーの列名(exclタグのnameかフィールド名)で対応付ける
+// 変換できなかったセルはReportに記録し、フィールドはゼロ値のまま読み込みを続ける
+// 開いているシートで行を出力済みの場合はエラーになる
+func (sheet *Sheet) ReadStructs(headerRow int, out interface{}) (Report, error) {
+	report := Report{}
+	value := reflect.ValueOf(out)
+	if value.Kind() != reflect.Ptr || value.IsNil() || value.Elem().Kind() != reflect.Slice {
+		return report, errors.New("The value should be a pointer to a slice of structs.")
+	}
+	slice := value.Elem()
+	elem, err := structSliceElem(slice.Type())
+	if err != nil {
+		return report, err
+	}
+	fields, err := getStructFields(elem)
+	if err != nil {
+		return report, err
+	}
+	headerFound := false
+	err = sheet.eachRow(func(row *Row) error {
+		if row.rowID < headerRow {
+			return nil
+		}
+		if row.rowID == headerRow {
+			headerFound = true
+			report.MissingHeaders = mapHeaders(row, fields)
+			return nil
+		}
+		if !headerFound {
+			return errors.New("The header row [" + strconv.Itoa(headerRow) + "] is not found.")
+		}
+		item := reflect.New(elem).Elem()
+		blank := true
+		for _, field := range fields {
+			if field.colNo <= 0 {
+				continue
+			}
+			cell := row.findCell(field.colNo)
+			if cell == nil || isBlankCell(cell) {
+				continue
+			}
+			blank = false
+			if err := getStructValue(cell, item.FieldByIndex(field.index)); err != nil {
+				report.Errors = append(report.Errors, &CellError{
+					Ref:   cellName(row.rowID, field.colNo),
+					Row:   row.rowID,
+					Col:   field.colNo,
+					Field: field.name,
+					Err:   err,
+				})
+			}
+		}
+		if blank {
+			return nil
+		}
+		if slice.Type().Elem().Kind() == reflect.Ptr {
+			item = item.Addr()
+		}
+		slice.Set(reflect.Append(slice, item))
+		report.Rows++
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+	if !headerFound {
+		return report, errors.New("The header row [" + strconv.Itoa(headerRow) + "] is not found.")
+	}
+	return report, nil
+}
+
+// mapHeaders ヘッダー行の列名からフィールドの列番号を設定し、見つからなかった列名を返す
+func mapHeaders(header *Row, fields []structField) []string {
+	cols := map[string]int{}
+	for _, cell := range header.GetCells() {
+		name, err := cell.GetString()
+		if err != nil {
+			continue
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		if _, ok := cols[name]; !ok {
+			cols[name] = cell.colNo
+		}
+	}
+	var missing []string
+	for i := range fields {
+		if fields[i].hasCol {
+			continue
+		}
+		if colNo, ok := cols[strings.ToLower(strings.TrimSpace(fields[i].name))]; ok {
+			fields[i].colNo = colNo
+		} else {
+			fields[i].colNo = 0
+			missing = append(missing, fields[i].name)
+		}
+	}
+	return missing
+}
+
+// isBlankCell 値のない、または空白文字だけのセルか確認する
+func isBlankCell(cell *Cell) bool {
+	switch cell.Type() {
+	case CellTypeBlank:
+		return true
+	case CellTypeSharedString, CellTypeInlineString, CellTypeString:
+		str, err := cell.GetString()
+		return err == nil && strings.TrimSpace(str) == ""
+	}
+	return false
+}
+
+// getStructValue セルの値をフィールドの型に変換してセットする
+func getStructValue(cell *Cell, v reflect.Value) error {
+	if v.Kind() == reflect.Ptr {
+		ptr := reflect.New(v.Type().Elem())
+		if err := getStructValue(cell, ptr.Elem()); err != nil {
+			return err
+		}
+		v.Set(ptr)
+		return nil
+	}
+	if v.Type() == timeType {
+		t, err := cell.GetTime()
+		if err != nil {
+			return errNotDate
+		}
+		v.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		str, err := cell.GetString()
+		if err != nil {
+			return err
+		}
+		v.SetString(str)
+	case reflect.Bool:
+		b, err := cell.GetBool()
+		if err != nil {
+			return errNotBool
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f, err := cell.GetNumber()
+		if err != nil {
+			return errNotNumber
+		}
+		if f != math.Trunc(f) {
+			return errNotInteger
+		}
+		if f < math.MinInt64 || f >= math.MaxInt64 || v.OverflowInt(int64(f)) {
+			return errOutOfRange
+		}
+		v.SetInt(int64(f))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f, err := cell.GetNumber()
+		if err != nil {
+			return errNotNumber
+		}
+		if f != math.Trunc(f) {
+			return errNotInteger
+		}
+		if f < 0 || f >= math.MaxUint64 || v.OverflowUint(uint64(f)) {
+			return errOutOfRange
+		}
+		v.SetUint(uint64(f))
+	case reflect.Float32, reflect.Float64:
+		f, err := cell.GetNumber()
+		if err != nil {
+			return errNotNumber
+		}
+		if v.OverflowFloat(f) {
+			return errOutOfRange
+		}
+		v.SetFloat(f)
+	}
+	return nil
+}
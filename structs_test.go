@@ -0,0 +1,223 @@
+package excl
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+type testItem struct {
+	Name     string    `excl:"name=Item Name,width=20"`
+	Amount   float64   `excl:"name=Amount,col=D,numfmt=#,##0.00,width=12"`
+	Count    int       `excl:"name=Count"`
+	Date     time.Time `excl:"name=Date,col=B"`
+	Paid     bool
+	Note     *string `excl:"name=Note,col=F"`
+	Ignored  string  `excl:"-"`
+	internal string
+}
+
+func TestParseStructTag(t *testing.T) {
+	values, err := parseStructTag("name=Amount,col=C,numfmt=#,##0.00,width=12")
+	if err != nil {
+		t.Fatal("tag should be parsed.", err.Error())
+	}
+	if values["name"] != "Amount" || values["col"] != "C" || values["numfmt"] != "#,##0.00" || values["width"] != "12" {
+		t.Error("tag values are invalid.", values)
+	}
+	if _, err = parseStructTag("hoge"); err == nil {
+		t.Error("tag should not be parsed.")
+	}
+	if col := parseColumn("AA"); col != 27 {
+		t.Error("column should be 27 but", col)
+	}
+	if col := parseColumn("A1"); col != 0 {
+		t.Error("column should be invalid but", col)
+	}
+}
+
+func TestWriteStructs(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	note := "note"
+	date := time.Date(2017, 4, 1, 0, 0, 0, 0, time.UTC)
+	items := []*testItem{
+		{Name: "apple", Amount: 1234.5, Count: 3, Date: date, Paid: true, Note: &note, Ignored: "ignored"},
+		nil,
+		{Name: "orange", Amount: 10},
+	}
+	if err := sheet.WriteStructs(2, items); err != nil {
+		t.Fatal("structs should be written.", err.Error())
+	}
+	header := sheet.GetRow(2)
+	for colNo, name := range map[int]string{1: "Item Name", 4: "Amount", 5: "Count", 2: "Date", 3: "Paid", 6: "Note"} {
+		if v, _ := header.GetCell(colNo).GetString(); v != name {
+			t.Error("header should be", name, "but", v)
+		}
+	}
+	if len(header.GetCells()) != 6 {
+		t.Error("header cell count should be 6 but", len(header.GetCells()))
+	}
+	row := sheet.GetRow(3)
+	if v, _ := row.GetCell(1).GetString(); v != "apple" {
+		t.Error("name should be apple but", v)
+	}
+	cell := row.GetCell(4)
+	if v, _ := cell.GetNumber(); v != 1234.5 {
+		t.Error("amount should be 1234.5 but", v)
+	}
+	if code := workbook.Styles.getNumFmtCode(cell.GetStyle().NumFmtID); code != "#,##0.00" {
+		t.Error("number format should be #,##0.00 but", code)
+	}
+	if v, _ := row.GetCell(5).GetNumber(); v != 3 {
+		t.Error("count should be 3 but", v)
+	}
+	if v, _ := row.GetCell(2).GetTime(); !v.Equal(date) {
+		t.Error("date should be", date, "but", v)
+	}
+	if v, _ := row.GetCell(3).GetBool(); !v {
+		t.Error("paid should be true.")
+	}
+	if v, _ := row.GetCell(6).GetString(); v != "note" {
+		t.Error("note should be note but", v)
+	}
+	if v, _ := sheet.GetRow(5).GetCell(1).GetString(); v != "orange" {
+		t.Error("nil item should be skipped and orange should be in row 5 but", v)
+	}
+	if sheet.GetRow(5).GetCell(6).Type() != CellTypeBlank {
+		t.Error("nil pointer field should be blank.")
+	}
+	widths := map[int]float64{}
+	for _, info := range sheet.colInfos {
+		widths[info.min] = info.width
+	}
+	if widths[1] != 20 || widths[4] != 12 {
+		t.Error("column widths are invalid.", sheet.colInfos)
+	}
+
+	if err := sheet.WriteStructs(1, "hoge"); err == nil {
+		t.Error("string should not be written.")
+	}
+	if err := sheet.WriteStructs(1, []struct{ M map[string]int }{{}}); err == nil {
+		t.Error("map field should not be written.")
+	}
+	if err := sheet.WriteStructs(1, []struct {
+		A string `excl:"col=B"`
+		B string `excl:"col=A"`
+		C string
+	}{{}}); err == nil {
+		t.Error("duplicate column should not be written.")
+	}
+	if err := sheet.WriteStructs(0, items); err == nil {
+		t.Error("row number 0 should not be written.")
+	}
+}
+
+type testUpload struct {
+	Name   string     `excl:"name=Item Name"`
+	Amount float64    `excl:"name=amount"`
+	Count  int        `excl:"name=Count"`
+	Date   time.Time  `excl:"name=Date"`
+	Paid   bool       `excl:"name=Paid"`
+	Code   string     `excl:"col=G"`
+	Due    *time.Time `excl:"name=Due"`
+	Memo   string
+}
+
+func TestWriteStructsNotFinite(t *testing.T) {
+	type R struct {
+		Value float64
+		Rate  *float32
+	}
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	inf := float32(math.Inf(-1))
+	tests := []struct {
+		items    []R
+		expected string
+	}{
+		{[]R{{Value: 1}, {Value: math.NaN()}}, "The field [Value] of the row [3] is not a finite number."},
+		{[]R{{Value: math.Inf(1)}}, "The field [Value] of the row [2] is not a finite number."},
+		{[]R{{Rate: &inf}}, "The field [Rate] of the row [2] is not a finite number."},
+	}
+	for _, test := range tests {
+		if err := sheet.WriteStructs(1, test.items); err == nil || err.Error() != test.expected {
+			t.Error("not finite number should be error.", test.expected, err)
+		}
+	}
+}
+
+func TestReadStructs(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	header := sheet.GetRow(2)
+	for i, name := range []string{"Item Name", " AMOUNT ", "Count", "Date", "Paid", "Due"} {
+		header.SetString(name, i+1)
+	}
+	date := time.Date(2017, 4, 1, 0, 0, 0, 0, time.UTC)
+	row := sheet.GetRow(3)
+	row.SetString("apple", 1)
+	row.SetNumber("1234.5", 2)
+	row.SetNumber("3", 3)
+	row.SetNumber(timeToExcelTime(date), 4)
+	row.SetString("TRUE", 5)
+	row.SetDate(date, 6)
+	row.SetString("A-1", 7)
+	row = sheet.GetRow(5)
+	row.SetString("orange", 1)
+	row.SetString("hoge", 2)
+	row.SetNumber("1.5", 3)
+	row.SetString("tomorrow", 4)
+	row.SetString("maybe", 5)
+	sheet.GetRow(6).SetString("  ", 1)
+
+	var items []testUpload
+	report, err := sheet.ReadStructs(2, &items)
+	if err != nil {
+		t.Fatal("structs should be read.", err.Error())
+	}
+	if report.Rows != 2 || len(items) != 2 {
+		t.Fatal("2 rows should be read but", report.Rows, len(items))
+	}
+	item := items[0]
+	if item.Name != "apple" || item.Amount != 1234.5 || item.Count != 3 || !item.Date.Equal(date) || !item.Paid || item.Code != "A-1" {
+		t.Error("item is invalid.", item)
+	}
+	if item.Due == nil || !item.Due.Equal(date) {
+		t.Error("due should be", date, "but", item.Due)
+	}
+	if items[1].Name != "orange" || items[1].Due != nil {
+		t.Error("item is invalid.", items[1])
+	}
+	var messages []string
+	for _, e := range report.Errors {
+		messages = append(messages, e.Error())
+	}
+	expected := []string{"B5: not a number", "C5: not an integer", "D5: not a date", "E5: not a boolean"}
+	if len(messages) != len(expected) {
+		t.Fatal("errors should be", expected, "but", messages)
+	}
+	for i := range expected {
+		if messages[i] != expected[i] {
+			t.Error("error should be", expected[i], "but", messages[i])
+		}
+	}
+	if len(report.MissingHeaders) != 1 || report.MissingHeaders[0] != "Memo" {
+		t.Error("missing header should be Memo but", report.MissingHeaders)
+	}
+
+	sheet.Close()
+	var ptrs []*testUpload
+	if report, err = sheet.ReadStructs(2, &ptrs); err != nil || len(ptrs) != 2 || ptrs[0].Name != "apple" {
+		t.Error("structs should be read from closed sheet.", err, len(ptrs))
+	}
+	if _, err = sheet.ReadStructs(10, &ptrs); err == nil {
+		t.Error("header row should not be found.")
+	}
+	if _, err = sheet.ReadStructs(2, items); err == nil {
+		t.Error("slice which is not pointer should not be read.")
+	}
+}
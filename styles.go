@@ -3,15 +3,21 @@ package excl
 import (
 	"encoding/xml"
 	"errors"
-	"os"
-	"path/filepath"
 	"strconv"
+	"strings"
 )
 
 const defaultMaxNumfmt = 200
 
+// styleSheetOrder styleSheetの子タグの順序
+var styleSheetOrder = []string{
+	"numFmts", "fonts", "fills", "borders", "cellStyleXfs", "cellXfs",
+	"cellStyles", "dxfs", "tableStyles", "colors", "extLst",
+}
+
 // Styles スタイルの情報を持った構造体
 type Styles struct {
+	storage          Storage
 	path             string
 	styles           *Tag
 	numFmts          *Tag
@@ -20,11 +26,13 @@ type Styles struct {
 	borders          *Tag
 	cellStyleXfs     *Tag
 	cellXfs          *Tag
+	dxfs             *Tag
 	styleList        []*Style
 	numFmtNumber     int
 	fontCount        int
 	fillCount        int
 	borderCount      int
+	dxfCount         int
 	backgroundColors map[string]int
 }
 
@@ -45,6 +53,10 @@ type Style struct {
 	Horizontal        string
 	Vertical          string
 	Wrap              int
+	// Locked シートを保護した場合にセルを編集できなくする。nilの場合は既定(ロックする)
+	Locked *bool
+	// FormulaHidden シートを保護した場合に数式を表示しない。nilの場合は既定(表示する)
+	FormulaHidden *bool
 }
 
 // Font フォントの設定
@@ -72,10 +84,8 @@ type Border struct {
 }
 
 // createStyles styles.xmlを作成する
-func createStyles(dir string) error {
-	os.Mkdir(filepath.Join(dir, "xl"), 0755)
-	path := filepath.Join(dir, "xl", "styles.xml")
-	f, err := os.Create(path)
+func createStyles(storage Storage) error {
+	f, err := storage.Create("xl/styles.xml")
 	if err != nil {
 		return err
 	}
@@ -94,10 +104,13 @@ func createStyles(dir string) error {
 
 // OpenStyles styles.xmlファイルを開く
 func OpenStyles(dir string) (*Styles, error) {
-	var f *os.File
-	var err error
-	path := filepath.Join(dir, "xl", "styles.xml")
-	f, err = os.Open(path)
+	return openStyles(newDirStorage(dir))
+}
+
+// openStyles styles.xmlファイルをストレージから開く
+func openStyles(storage Storage) (*Styles, error) {
+	path := "xl/styles.xml"
+	f, err := storage.Open(path)
 	if err != nil {
 		return nil, err
 	}
@@ -107,7 +120,7 @@ func OpenStyles(dir string) (*Styles, error) {
 	if err != nil {
 		return nil, err
 	}
-	styles := &Styles{styles: tag, path: path}
+	styles := &Styles{styles: tag, storage: storage, path: path}
 	err = styles.setData()
 	if err != nil {
 		return nil, err
@@ -120,7 +133,7 @@ func (styles *Styles) Close() error {
 	if styles == nil {
 		return nil
 	}
-	f, err := os.Create(styles.path)
+	f, err := styles.storage.Create(styles.path)
 	if err != nil {
 		return err
 	}
@@ -163,6 +176,13 @@ func (styles *Styles) setData() error {
 			case "cellXfs":
 				styles.cellXfs = tag
 				styles.setStyleList()
+			case "dxfs":
+				styles.dxfs = tag
+				for _, dxf := range tag.Children {
+					if t, ok := dxf.(*Tag); ok && t.Name.Local == "dxf" {
+						styles.dxfCount++
+					}
+				}
 			}
 		}
 	}
@@ -222,6 +242,17 @@ func (styles *Styles) setStyleList() {
 						}
 					}
 				}
+				// protection
+				if protection := t.getChild("protection"); protection != nil {
+					if val, err := protection.getAttr("locked"); err == nil {
+						locked := val == "1" || val == "true"
+						style.Locked = &locked
+					}
+					if val, err := protection.getAttr("hidden"); err == nil {
+						hidden := val == "1" || val == "true"
+						style.FormulaHidden = &hidden
+					}
+				}
 				styles.styleList = append(styles.styleList, style)
 			}
 		}
@@ -293,8 +324,70 @@ func (styles *Styles) SetNumFmt(format string) int {
 	return styles.numFmtNumber - 1
 }
 
+// getNumFmtCode 数値フォーマットIDからフォーマット文字列を取得する
+func (styles *Styles) getNumFmtCode(id int) string {
+	if styles.numFmts == nil {
+		return ""
+	}
+	for _, child := range styles.numFmts.Children {
+		switch tag := child.(type) {
+		case *Tag:
+			if v, _ := tag.getAttr("numFmtId"); v != strconv.Itoa(id) {
+				continue
+			}
+			code, _ := tag.getAttr("formatCode")
+			return code
+		}
+	}
+	return ""
+}
+
+// isDateFormat 数値フォーマットが日付の書式かを確認する
+func (styles *Styles) isDateFormat(id int) bool {
+	if (14 <= id && id <= 22) || (27 <= id && id <= 36) || (45 <= id && id <= 47) || (50 <= id && id <= 58) {
+		return true
+	}
+	code := styles.getNumFmtCode(id)
+	if code == "" {
+		return false
+	}
+	// 文字列リテラル、エスケープ、色指定などを除いて判定する
+	var b strings.Builder
+	inQuote := false
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case c == '"':
+			inQuote = !inQuote
+		case inQuote:
+		case c == '\\' || c == '_' || c == '*':
+			i++
+		case c == '[':
+			end := strings.IndexByte(code[i:], ']')
+			if end < 0 {
+				i = len(code)
+				break
+			}
+			if elapsed := strings.ToLower(code[i+1 : i+end]); elapsed != "" && strings.Trim(elapsed, "hms") == "" {
+				return true
+			}
+			i += end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.ContainsAny(strings.ToLower(b.String()), "ymdhs")
+}
+
 // SetFont フォント情報を追加する
 func (styles *Styles) SetFont(font Font) int {
+	styles.fonts.Children = append(styles.fonts.Children, fontTag(font))
+	styles.fontCount++
+	return styles.fontCount - 1
+}
+
+// fontTag フォント情報からfontタグを作成する
+func fontTag(font Font) *Tag {
 	tag := &Tag{Name: xml.Name{Local: "font"}}
 	var t *Tag
 	if font.Size > 0 {
@@ -324,9 +417,7 @@ func (styles *Styles) SetFont(font Font) int {
 		t = &Tag{Name: xml.Name{Local: "u"}}
 		tag.Children = append(tag.Children, t)
 	}
-	styles.fonts.Children = append(styles.fonts.Children, tag)
-	styles.fontCount++
-	return styles.fontCount - 1
+	return tag
 }
 
 // SetBackgroundColor 背景色を追加する
@@ -351,6 +442,13 @@ func (styles *Styles) SetBackgroundColor(color string) int {
 
 // SetBorder 罫線を設定する
 func (styles *Styles) SetBorder(border Border) int {
+	styles.borders.Children = append(styles.borders.Children, borderTag(border))
+	styles.borderCount++
+	return styles.borderCount - 1
+}
+
+// borderTag 罫線の設定からborderタグを作成する
+func borderTag(border Border) *Tag {
 	var color *Tag
 	tag := &Tag{Name: xml.Name{Local: "border"}}
 	left := &Tag{Name: xml.Name{Local: "left"}}
@@ -396,9 +494,40 @@ func (styles *Styles) SetBorder(border Border) int {
 	tag.Children = append(tag.Children, right)
 	tag.Children = append(tag.Children, top)
 	tag.Children = append(tag.Children, bottom)
-	styles.borders.Children = append(styles.borders.Children, tag)
-	styles.borderCount++
-	return styles.borderCount - 1
+	return tag
+}
+
+// AddDxf 条件付き書式などで使用する差分の書式を追加してdxfIdを返す
+// fillは背景色、numFmtは数値フォーマットで、空の場合は設定しない
+func (styles *Styles) AddDxf(font Font, fill string, border Border, numFmt string) int {
+	tag := &Tag{Name: xml.Name{Local: "dxf"}}
+	if f := fontTag(font); len(f.Children) > 0 {
+		tag.Children = append(tag.Children, f)
+	}
+	if numFmt != "" {
+		t := &Tag{Name: xml.Name{Local: "numFmt"}}
+		t.setAttr("numFmtId", strconv.Itoa(styles.SetNumFmt(numFmt)))
+		t.setAttr("formatCode", numFmt)
+		tag.Children = append(tag.Children, t)
+	}
+	if fill != "" {
+		// dxfの塗りつぶしはfgColorではなくbgColorで指定する
+		bgColor := &Tag{Name: xml.Name{Local: "bgColor"}}
+		bgColor.setAttr("rgb", fill)
+		patternFill := &Tag{Name: xml.Name{Local: "patternFill"}, Children: []interface{}{bgColor}}
+		tag.Children = append(tag.Children, &Tag{Name: xml.Name{Local: "fill"}, Children: []interface{}{patternFill}})
+	}
+	if border.Left != nil || border.Right != nil || border.Top != nil || border.Bottom != nil {
+		tag.Children = append(tag.Children, borderTag(border))
+	}
+	if styles.dxfs == nil {
+		styles.dxfs = &Tag{Name: xml.Name{Local: "dxfs"}}
+		styles.styles.insertChild(styles.dxfs, styleSheetOrder)
+	}
+	styles.dxfs.Children = append(styles.dxfs.Children, tag)
+	styles.dxfCount++
+	styles.dxfs.setAttr("count", strconv.Itoa(styles.dxfCount))
+	return styles.dxfCount - 1
 }
 
 // SetStyle セルの書式を設定
@@ -412,7 +541,9 @@ func (styles *Styles) SetStyle(style *Style) int {
 			s.XfID == style.XfID &&
 			s.Horizontal == style.Horizontal &&
 			s.Vertical == style.Vertical &&
-			s.Wrap == style.Wrap {
+			s.Wrap == style.Wrap &&
+			s.isLocked() == style.isLocked() &&
+			s.isFormulaHidden() == style.isFormulaHidden() {
 			return index
 		}
 	}
@@ -439,6 +570,14 @@ func (styles *Styles) SetCellXfs(style *Style) int {
 		Vertical:   style.Vertical,
 		Wrap:       style.Wrap,
 	}
+	if style.Locked != nil {
+		locked := *style.Locked
+		s.Locked = &locked
+	}
+	if style.FormulaHidden != nil {
+		hidden := *style.FormulaHidden
+		s.FormulaHidden = &hidden
+	}
 	attr := []xml.Attr{
 		xml.Attr{
 			Name:  xml.Name{Local: "numFmtId"},
@@ -511,12 +650,45 @@ func (styles *Styles) SetCellXfs(style *Style) int {
 		})
 		s.applyAlignment = 1
 	}
+	if style.Locked != nil || style.FormulaHidden != nil {
+		protection := &Tag{Name: xml.Name{Local: "protection"}}
+		if style.Locked != nil {
+			protection.setAttr("locked", boolString(*style.Locked))
+		}
+		if style.FormulaHidden != nil {
+			protection.setAttr("hidden", boolString(*style.FormulaHidden))
+		}
+		tag.Children = append(tag.Children, protection)
+		tag.Attr = append(tag.Attr, xml.Attr{
+			Name:  xml.Name{Local: "applyProtection"},
+			Value: "1",
+		})
+		s.applyProtection = 1
+	}
 	s.xf = tag
 	styles.cellXfs.Children = append(styles.cellXfs.Children, tag)
 	styles.styleList = append(styles.styleList, s)
 	return len(styles.styleList) - 1
 }
 
+// isLocked セルがロックされるか。指定がない場合はロックされる
+func (style *Style) isLocked() bool {
+	return style.Locked == nil || *style.Locked
+}
+
+// isFormulaHidden 数式が非表示になるか。指定がない場合は表示される
+func (style *Style) isFormulaHidden() bool {
+	return style.FormulaHidden != nil && *style.FormulaHidden
+}
+
+// boolString 真偽値を"1"または"0"にする
+func boolString(val bool) string {
+	if val {
+		return "1"
+	}
+	return "0"
+}
+
 // MarshalXML stylesからXMLを作り直す
 func (styles *Styles) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 	start.Name = styles.styles.Name
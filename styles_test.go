@@ -255,6 +255,39 @@ func TestSetBorder(t *testing.T) {
 	}
 }
 
+func TestAddDxf(t *testing.T) {
+	r := strings.NewReader(`<styleSheet><fonts></fonts><cellXfs></cellXfs><cellStyles></cellStyles><tableStyles></tableStyles></styleSheet>`)
+	tag := &Tag{}
+	xml.NewDecoder(r).Decode(tag)
+	styles := &Styles{styles: tag}
+	styles.setData()
+	index := styles.AddDxf(Font{Color: "FF9C0006"}, "FFFFC7CE", Border{}, "")
+	if index != 0 {
+		t.Error("index should be 0 but", index)
+	}
+	index = styles.AddDxf(Font{Bold: true}, "", Border{Bottom: &BorderSetting{Style: "thin"}}, "0.0%")
+	if index != 1 {
+		t.Error("index should be 1 but", index)
+	}
+	b := new(bytes.Buffer)
+	xml.NewEncoder(b).Encode(styles)
+	expected := `<cellStyles></cellStyles><dxfs count="2">` +
+		`<dxf><font><color rgb="FF9C0006"></color></font><fill><patternFill><bgColor rgb="FFFFC7CE"></bgColor></patternFill></fill></dxf>` +
+		`<dxf><font><b></b></font><numFmt numFmtId="200" formatCode="0.0%"></numFmt><border><left></left><right></right><top></top><bottom style="thin"></bottom></border></dxf>` +
+		`</dxfs><tableStyles></tableStyles>`
+	if !strings.Contains(b.String(), expected) {
+		t.Error("dxfs should be written before tableStyles.", b.String())
+	}
+	r = strings.NewReader(`<styleSheet><dxfs count="1"><dxf></dxf></dxfs></styleSheet>`)
+	tag = &Tag{}
+	xml.NewDecoder(r).Decode(tag)
+	styles = &Styles{styles: tag}
+	styles.setData()
+	if index = styles.AddDxf(Font{Italic: true}, "", Border{}, ""); index != 1 {
+		t.Error("index should be 1 but", index)
+	}
+}
+
 func TestSetStyle(t *testing.T) {
 	r := strings.NewReader(`<cellXfs></cellXfs>`)
 	tag := &Tag{}
@@ -297,6 +330,41 @@ func TestSetStyle(t *testing.T) {
 	}
 }
 
+func TestSetStyleProtection(t *testing.T) {
+	r := strings.NewReader(`<cellXfs><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"></xf>` +
+		`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0" applyProtection="1"><protection locked="0"></protection></xf></cellXfs>`)
+	tag := &Tag{}
+	xml.NewDecoder(r).Decode(tag)
+	styles := &Styles{cellXfs: tag}
+	styles.setStyleList()
+	if style := styles.GetStyle(1); style.Locked == nil || *style.Locked || style.FormulaHidden != nil {
+		t.Error("protection should be read.", style.Locked, style.FormulaHidden)
+	}
+	locked, hidden := false, true
+	if index := styles.SetStyle(&Style{Locked: &locked}); index != 1 {
+		t.Error("index should be 1 but", index)
+	}
+	// 既定と同じ値の場合は指定がない書式と同じになる
+	locked = true
+	if index := styles.SetStyle(&Style{Locked: &locked}); index != 0 {
+		t.Error("index should be 0 but", index)
+	}
+	locked = false
+	index := styles.SetStyle(&Style{Locked: &locked, FormulaHidden: &hidden})
+	if index != 2 {
+		t.Error("index should be 2 but", index)
+	}
+	b := new(bytes.Buffer)
+	xml.NewEncoder(b).Encode(styles.cellXfs.Children[index].(*Tag))
+	if b.String() != `<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0" applyProtection="1"><protection locked="0" hidden="1"></protection></xf>` {
+		t.Error("xml is corrupt [", b.String(), "]")
+	}
+	hidden = false
+	if style := styles.GetStyle(index); !*style.FormulaHidden {
+		t.Error("style should not share the value.")
+	}
+}
+
 func TestGetStyle(t *testing.T) {
 	styles := &Styles{}
 	if styles.GetStyle(0) != nil {
@@ -0,0 +1,367 @@
+package excl
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	tableContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.table+xml"
+	relTypeTable     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/table"
+	// defaultTableStyle テーブルのスタイルを指定しない場合のスタイル
+	defaultTableStyle = "TableStyleMedium2"
+)
+
+// TableOptions テーブルの設定
+type TableOptions struct {
+	// Name テーブル名(空の場合は"TableN")。ブック内で重複しない名前にする
+	Name string
+	// StyleName テーブルのスタイル(空の場合は"TableStyleMedium2")
+	StyleName string
+	// ShowRowStripes 縞模様の行を表示する
+	ShowRowStripes bool
+	// TotalsRow 範囲の最終行を集計行にする
+	TotalsRow bool
+}
+
+// Table テーブルの情報
+type Table struct {
+	// Name テーブル名
+	Name string
+	// Sheet テーブルのあるシート名
+	Sheet string
+	// Ref 見出しと集計行を含む範囲("A1:D10"など)
+	Ref string
+	// Columns 列の名前
+	Columns []string
+	// StyleName テーブルのスタイル
+	StyleName string
+	// ShowRowStripes 縞模様の行を表示する
+	ShowRowStripes bool
+	// TotalsRow 最終行が集計行
+	TotalsRow bool
+}
+
+// tablePart テーブルパーツ(tableN.xml)の情報
+type tablePart struct {
+	path string
+	tag  *Tag
+}
+
+// AddTable add the table to the range such as "A1:D10"
+// The first row of the range is the header row and its values are used as column names.
+// Header cells are set as strings and blank or duplicated ones are filled with generated names.
+// The header row should not be output yet.
+func (sheet *Sheet) AddTable(ref string, opts TableOptions) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	r, err := parseRange(ref)
+	if err != nil {
+		return err
+	}
+	minRows := 2
+	if opts.TotalsRow {
+		minRows = 3
+	}
+	if r.maxRow-r.minRow+1 < minRows {
+		return errors.New("The range [" + ref + "] should contain the header row and data rows.")
+	}
+	if r.minRow <= sheet.outputRowNo {
+		return errors.New("The header row of the range [" + ref + "] is already output.")
+	}
+	for _, merged := range sheet.mergedRanges {
+		if r.overlaps(merged) {
+			return errors.New("The range [" + ref + "] overlaps the merged range [" + merged.String() + "].")
+		}
+	}
+	if autoFilter := sheet.worksheet.getChild("autoFilter"); autoFilter != nil {
+		filterRef, _ := autoFilter.getAttr("ref")
+		if f, err := parseRange(filterRef); err == nil && r.overlaps(f) {
+			return errors.New("The range [" + ref + "] overlaps the auto filter [" + filterRef + "].")
+		}
+	}
+	rels, err := sheet.getRels()
+	if err != nil {
+		return err
+	}
+	tables, err := sheet.tables(sheet.storage, rels)
+	if err != nil {
+		return err
+	}
+	for _, table := range tables {
+		if t, err := parseRange(table.Ref); err == nil && r.overlaps(t) {
+			return errors.New("The range [" + ref + "] overlaps the table [" + table.Name + "].")
+		}
+	}
+	parts, err := readTableParts(sheet.storage)
+	if err != nil {
+		return err
+	}
+	id := 1
+	for _, part := range parts {
+		str, _ := part.tag.getAttr("id")
+		if n, _ := strconv.Atoi(str); n >= id {
+			id = n + 1
+		}
+	}
+	name := opts.Name
+	if name == "" {
+		name = "Table" + strconv.Itoa(id)
+	}
+	if !isValidName(name) {
+		return errors.New("The table name [" + name + "] is invalid.")
+	}
+	for _, part := range parts {
+		if n, _ := part.tag.getAttr("name"); strings.EqualFold(n, name) {
+			return errors.New("The table name [" + name + "] already exists.")
+		}
+	}
+	if sheet.workbook != nil {
+		for i := -1; i < len(sheet.workbook.sheets); i++ {
+			if sheet.workbook.findDefinedName(name, i) != nil {
+				return errors.New("The name [" + name + "] is already defined.")
+			}
+		}
+	}
+
+	n := 1
+	for ; sheet.partExists(fmt.Sprintf("xl/tables/table%d.xml", n)); n++ {
+	}
+	tablePath := fmt.Sprintf("xl/tables/table%d.xml", n)
+	tag := tableTag(id, name, r, sheet.tableColumns(r), opts)
+	f, err := sheet.storage.Create(tablePath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	f.WriteString("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n")
+	if err = xml.NewEncoder(f).Encode(tag); err != nil {
+		return err
+	}
+	if err = f.Close(); err != nil {
+		return err
+	}
+	if sheet.workbook != nil {
+		sheet.workbook.types.addOverride("/"+tablePath, tableContentType)
+	}
+	rid := rels.addRel(relTypeTable, relativePath(path.Dir(sheet.sheetPath), tablePath), false)
+	tableParts := sheet.worksheet.getChild("tableParts")
+	if tableParts == nil {
+		tableParts = &Tag{Name: xml.Name{Local: "tableParts"}}
+		sheet.worksheet.insertChild(tableParts, worksheetOrder)
+	}
+	part := &Tag{Name: xml.Name{Local: "tablePart"}}
+	part.setAttr("r:id", rid)
+	tableParts.Children = append(tableParts.Children, part)
+	tableParts.setAttr("count", strconv.Itoa(len(tagChildren(tableParts, "tablePart"))))
+	return nil
+}
+
+// Tables get the tables in the sheet
+func (sheet *Sheet) Tables() ([]Table, error) {
+	if !sheet.opened {
+		return nil, errors.New("The sheet is not opened.")
+	}
+	rels, err := sheet.getRels()
+	if err != nil {
+		return nil, err
+	}
+	return sheet.tables(sheet.storage, rels)
+}
+
+// Tables get the tables in all sheets of the workbook
+func (workbook *Workbook) Tables() ([]Table, error) {
+	var tables []Table
+	for _, sheet := range workbook.sheets {
+		rels := sheet.rels
+		if rels == nil {
+			var err error
+			if rels, err = openSheetRels(workbook.storage, sheet.partName()); err != nil {
+				return nil, err
+			}
+		}
+		t, err := sheet.tables(workbook.storage, rels)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, t...)
+	}
+	return tables, nil
+}
+
+// GetTable get the table by the name
+func (workbook *Workbook) GetTable(name string) (*Table, error) {
+	tables, err := workbook.Tables()
+	if err != nil {
+		return nil, err
+	}
+	for i := range tables {
+		if strings.EqualFold(tables[i].Name, name) {
+			return &tables[i], nil
+		}
+	}
+	return nil, errors.New("The table [" + name + "] does not exist.")
+}
+
+// DataRange get the range of the data rows such as "Sheet1!$A$2:$D$9"
+// The header row and the totals row are not included.
+func (table *Table) DataRange() (string, error) {
+	r, err := parseRange(table.Ref)
+	if err != nil {
+		return "", err
+	}
+	r.minRow++
+	if table.TotalsRow {
+		r.maxRow--
+	}
+	if r.minRow > r.maxRow {
+		return "", errors.New("The table [" + table.Name + "] has no data rows.")
+	}
+	return absoluteRef(table.Sheet, r), nil
+}
+
+// tables シートのリレーションからテーブルの情報を取得する
+func (sheet *Sheet) tables(storage Storage, rels *SheetRels) ([]Table, error) {
+	var tables []Table
+	for _, rel := range rels.rels.Rels {
+		if rel.Type != relTypeTable {
+			continue
+		}
+		part, err := readTablePart(storage, resolveTarget(sheet.partName(), rel.Target))
+		if err != nil {
+			return nil, err
+		}
+		table := parseTable(part.tag)
+		table.Sheet = sheet.xml.Name
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// tableColumns 見出し行から列の名前を取得し、見出しのセルを文字列にする
+// 空白や重複がある場合は名前を作成する
+func (sheet *Sheet) tableColumns(r cellRange) []string {
+	row := sheet.GetRow(r.minRow)
+	used := map[string]bool{}
+	columns := make([]string, 0, r.maxCol-r.minCol+1)
+	for colNo := r.minCol; colNo <= r.maxCol; colNo++ {
+		name := ""
+		if cell := row.findCell(colNo); cell != nil {
+			name, _ = cell.GetString()
+		}
+		if name == "" {
+			name = "Column" + strconv.Itoa(colNo-r.minCol+1)
+		}
+		for base, i := name, 2; used[strings.ToLower(name)]; i++ {
+			name = base + strconv.Itoa(i)
+		}
+		row.GetCell(colNo).SetString(name)
+		used[strings.ToLower(name)] = true
+		columns = append(columns, name)
+	}
+	return columns
+}
+
+// tableTag テーブルパーツのtableタグを作成する
+func tableTag(id int, name string, r cellRange, columns []string, opts TableOptions) *Tag {
+	tag := &Tag{Name: xml.Name{Local: "table"}}
+	tag.setAttr("xmlns", "http://schemas.openxmlformats.org/spreadsheetml/2006/main")
+	tag.setAttr("id", strconv.Itoa(id))
+	tag.setAttr("name", name)
+	tag.setAttr("displayName", name)
+	tag.setAttr("ref", r.String())
+	filterRange := r
+	if opts.TotalsRow {
+		tag.setAttr("totalsRowCount", "1")
+		filterRange.maxRow--
+	} else {
+		tag.setAttr("totalsRowShown", "0")
+	}
+	autoFilter := &Tag{Name: xml.Name{Local: "autoFilter"}}
+	autoFilter.setAttr("ref", filterRange.String())
+	tableColumns := &Tag{Name: xml.Name{Local: "tableColumns"}}
+	tableColumns.setAttr("count", strconv.Itoa(len(columns)))
+	for i, column := range columns {
+		c := &Tag{Name: xml.Name{Local: "tableColumn"}}
+		c.setAttr("id", strconv.Itoa(i+1))
+		c.setAttr("name", column)
+		tableColumns.Children = append(tableColumns.Children, c)
+	}
+	style := &Tag{Name: xml.Name{Local: "tableStyleInfo"}}
+	styleName := opts.StyleName
+	if styleName == "" {
+		styleName = defaultTableStyle
+	}
+	style.setAttr("name", styleName)
+	style.setAttr("showFirstColumn", "0")
+	style.setAttr("showLastColumn", "0")
+	if opts.ShowRowStripes {
+		style.setAttr("showRowStripes", "1")
+	} else {
+		style.setAttr("showRowStripes", "0")
+	}
+	style.setAttr("showColumnStripes", "0")
+	tag.Children = []interface{}{autoFilter, tableColumns, style}
+	return tag
+}
+
+// parseTable tableタグからテーブルの情報を取得する
+func parseTable(tag *Tag) Table {
+	table := Table{}
+	table.Name, _ = tag.getAttr("name")
+	table.Ref, _ = tag.getAttr("ref")
+	if count, err := tag.getAttr("totalsRowCount"); err == nil && count != "0" {
+		table.TotalsRow = true
+	}
+	if tableColumns := tag.getChild("tableColumns"); tableColumns != nil {
+		for _, c := range tagChildren(tableColumns, "tableColumn") {
+			name, _ := c.getAttr("name")
+			table.Columns = append(table.Columns, name)
+		}
+	}
+	if style := tag.getChild("tableStyleInfo"); style != nil {
+		table.StyleName, _ = style.getAttr("name")
+		stripes, _ := style.getAttr("showRowStripes")
+		table.ShowRowStripes = stripes == "1" || stripes == "true"
+	}
+	return table
+}
+
+// readTablePart テーブルパーツを読み込む
+func readTablePart(storage Storage, name string) (*tablePart, error) {
+	f, err := storage.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	part := &tablePart{path: name, tag: &Tag{}}
+	if err = xml.NewDecoder(f).Decode(part.tag); err != nil {
+		return nil, err
+	}
+	return part, nil
+}
+
+// readTableParts ブック内のすべてのテーブルパーツを読み込む
+func readTableParts(storage Storage) ([]*tablePart, error) {
+	names, err := storage.List()
+	if err != nil {
+		return nil, err
+	}
+	var parts []*tablePart
+	for _, name := range names {
+		if !strings.HasPrefix(name, "xl/tables/") || path.Ext(name) != ".xml" {
+			continue
+		}
+		part, err := readTablePart(storage, name)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
@@ -0,0 +1,121 @@
+package excl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAddTable(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	row := sheet.GetRow(1)
+	row.SetString("Month", 1)
+	row.SetString("Sales", 2)
+	row.SetString("month", 4)
+	row.SetNumber(2024, 6)
+	for i := 2; i <= 5; i++ {
+		sheet.GetRow(i).SetNumber(i, 2)
+	}
+	if err := sheet.AddTable("A1:D5", TableOptions{Name: "Sales", ShowRowStripes: true, TotalsRow: true}); err != nil {
+		t.Fatal("table should be added.", err.Error())
+	}
+	if err := sheet.AddTable("F1:G3", TableOptions{StyleName: "TableStyleLight1"}); err != nil {
+		t.Fatal("table should be added.", err.Error())
+	}
+	if err := sheet.AddTable("D3:E6", TableOptions{}); err == nil {
+		t.Error("overlapped table should be error.")
+	}
+	if err := sheet.AddTable("A10:B12", TableOptions{Name: "sales"}); err == nil {
+		t.Error("duplicated table name should be error.")
+	}
+	if err := sheet.AddTable("A10:B12", TableOptions{Name: "A1"}); err == nil {
+		t.Error("invalid table name should be error.")
+	}
+	if err := sheet.AddTable("A10:B11", TableOptions{TotalsRow: true}); err == nil {
+		t.Error("table without data rows should be error.")
+	}
+	if s, _ := sheet.GetRow(1).GetCell(3).GetString(); s != "Column3" {
+		t.Error("blank header should be filled.", s)
+	}
+	if s, _ := sheet.GetRow(1).GetCell(4).GetString(); s != "month2" {
+		t.Error("duplicated header should be renamed.", s)
+	}
+	if cell := sheet.GetRow(1).GetCell(6); cell.Type() != CellTypeSharedString {
+		t.Error("number header should be set as string.", cell.Type())
+	}
+	tables, err := sheet.Tables()
+	if err != nil || len(tables) != 2 || tables[1].Name != "Table2" || tables[1].StyleName != "TableStyleLight1" {
+		t.Error("tables should be read.", tables, err)
+	}
+	sheet.Close()
+
+	str := readPart(workbook, "xl/tables/table1.xml")
+	expected := `<table xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" id="1" name="Sales" displayName="Sales" ref="A1:D5" totalsRowCount="1">` +
+		`<autoFilter ref="A1:D4"></autoFilter><tableColumns count="4"><tableColumn id="1" name="Month"></tableColumn><tableColumn id="2" name="Sales"></tableColumn>` +
+		`<tableColumn id="3" name="Column3"></tableColumn><tableColumn id="4" name="month2"></tableColumn></tableColumns>` +
+		`<tableStyleInfo name="TableStyleMedium2" showFirstColumn="0" showLastColumn="0" showRowStripes="1" showColumnStripes="0"></tableStyleInfo></table>`
+	if !strings.Contains(str, expected) {
+		t.Error("table should be written.", str)
+	}
+	if str = readPart(workbook, "xl/tables/table2.xml"); !strings.Contains(str, `id="2" name="Table2" displayName="Table2" ref="F1:G3" totalsRowShown="0">`) {
+		t.Error("table id should be unique.", str)
+	}
+	if str = readPart(workbook, "xl/worksheets/sheet1.xml"); !strings.Contains(str, `<tableParts count="2"><tablePart r:id="rId1"></tablePart><tablePart r:id="rId2"></tablePart></tableParts>`) {
+		t.Error("table parts should be written.", str)
+	}
+	if str = readPart(workbook, "xl/worksheets/_rels/sheet1.xml.rels"); !strings.Contains(str, `Target="../tables/table2.xml"`) {
+		t.Error("table relationships should be written.", str)
+	}
+	if !workbook.types.hasOverride("/xl/tables/table1.xml") {
+		t.Error("content type of table should be added.")
+	}
+
+	table, err := workbook.GetTable("SALES")
+	if err != nil {
+		t.Fatal("table should be found.", err.Error())
+	}
+	if table.Sheet != "Sheet1" || !table.TotalsRow || !table.ShowRowStripes || len(table.Columns) != 4 {
+		t.Error("table information is invalid.", table)
+	}
+	if ref, _ := table.DataRange(); ref != "Sheet1!$A$2:$D$4" {
+		t.Error("data range should be Sheet1!$A$2:$D$4.", ref)
+	}
+	if _, err = workbook.GetTable("Unknown"); err == nil {
+		t.Error("unknown table should be error.")
+	}
+
+	// 別のシートでもテーブルのIDは重複しない
+	sheet, _ = workbook.OpenSheet("Sheet2")
+	sheet.GetRow(1).SetString("Name", 1)
+	if err = sheet.AddTable("A1:A2", TableOptions{}); err != nil {
+		t.Fatal("table should be added.", err.Error())
+	}
+	sheet.Close()
+	if str = readPart(workbook, "xl/tables/table3.xml"); !strings.Contains(str, `id="3" name="Table3"`) {
+		t.Error("table id should be unique in the workbook.", str)
+	}
+	if tables, _ = workbook.Tables(); len(tables) != 3 || tables[2].Sheet != "Sheet2" {
+		t.Error("tables of the workbook should be read.", tables)
+	}
+}
+
+func TestAddTableAfterOutput(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	for i := 1; i <= 5; i++ {
+		sheet.GetRow(i).SetString("value", 1)
+	}
+	sheet.OutputThroughRowNo(2)
+	if err := sheet.AddTable("A2:A5", TableOptions{}); err == nil {
+		t.Error("table whose header row is output should be error.")
+	}
+	if err := sheet.AddTable("A3:A5", TableOptions{}); err != nil {
+		t.Error("table after output rows should be added.", err.Error())
+	}
+	sheet.Close()
+	if str := readPart(workbook, "xl/tables/table1.xml"); !strings.Contains(str, `ref="A3:A5"`) {
+		t.Error("table should be written.", str)
+	}
+}
@@ -37,6 +37,12 @@ func (t *Tag) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
 func (t *Tag) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	t.Name = start.Name
 	t.Attr = start.Attr
+	// 要素自身で宣言された接頭辞も要素名に使えるように先に読み込む
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "xmlns" {
+			t.XmlnsList = append(t.XmlnsList, attr)
+		}
+	}
 	for _, at := range t.XmlnsList {
 		if t.Name.Space != at.Value {
 			continue
@@ -50,7 +56,6 @@ func (t *Tag) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 	}
 	for index, attr := range start.Attr {
 		if attr.Name.Space == "xmlns" {
-			t.XmlnsList = append(t.XmlnsList, attr)
 			start.Attr[index].Name.Local = start.Attr[index].Name.Space + ":" + start.Attr[index].Name.Local
 			start.Attr[index].Name.Space = ""
 			continue
@@ -75,7 +80,8 @@ func (t *Tag) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
 		switch token.(type) {
 		case xml.StartElement:
 			tok := token.(xml.StartElement)
-			data := &Tag{XmlnsList: t.XmlnsList}
+			// 子要素での追加が兄弟要素に影響しないように容量を切り詰める
+			data := &Tag{XmlnsList: t.XmlnsList[:len(t.XmlnsList):len(t.XmlnsList)]}
 			if err := d.DecodeElement(&data, &tok); err != nil {
 				return err
 			}
@@ -128,3 +134,80 @@ func (t *Tag) getAttr(name string) (string, error) {
 	}
 	return "", errors.New("No attr found.")
 }
+
+// getChild 指定した名前の最初の子タグを取得する
+func (t *Tag) getChild(name string) *Tag {
+	for _, child := range t.Children {
+		if tag, ok := child.(*Tag); ok && tag.Name.Local == name {
+			return tag
+		}
+	}
+	return nil
+}
+
+// text タグ直下の文字列を連結して取得する
+func (t *Tag) text() string {
+	var str string
+	for _, child := range t.Children {
+		if data, ok := child.(xml.CharData); ok {
+			str += string(data)
+		}
+	}
+	return str
+}
+
+// childIndex 子タグの位置を取得する。ない場合は-1を返す
+func (t *Tag) childIndex(name string) int {
+	for i, child := range t.Children {
+		if tag, ok := child.(*Tag); ok && tag.Name.Local == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// insertChild 子タグをorderの順序の位置に挿入する
+// 同じ名前の子タグがある場合は置き換える
+func (t *Tag) insertChild(child *Tag, order []string) {
+	if i := t.childIndex(child.Name.Local); i >= 0 {
+		t.Children[i] = child
+		return
+	}
+	t.addChild(child, order)
+}
+
+// addChild 子タグをorderの順序の位置に追加する
+// 同じ名前の子タグがある場合はその後ろに追加する
+func (t *Tag) addChild(child *Tag, order []string) {
+	index := orderIndex(order, child.Name.Local)
+	pos := len(t.Children)
+	for i, c := range t.Children {
+		if tag, ok := c.(*Tag); ok && orderIndex(order, tag.Name.Local) > index {
+			pos = i
+			break
+		}
+	}
+	t.Children = append(t.Children, nil)
+	copy(t.Children[pos+1:], t.Children[pos:])
+	t.Children[pos] = child
+}
+
+// removeChild 指定した名前の子タグを削除する
+func (t *Tag) removeChild(name string) {
+	if i := t.childIndex(name); i >= 0 {
+		t.Children = append(t.Children[:i], t.Children[i+1:]...)
+	}
+}
+
+// orderIndex 順序の中の位置を取得する。separate_tagはcolsの位置として扱う
+func orderIndex(order []string, name string) int {
+	if name == "separate_tag" {
+		name = "cols"
+	}
+	for i, n := range order {
+		if n == name {
+			return i
+		}
+	}
+	return -1
+}
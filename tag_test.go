@@ -1,6 +1,9 @@
 package excl
 
-import "testing"
+import (
+	"encoding/xml"
+	"testing"
+)
 
 func TestSetAttr(t *testing.T) {
 	tag := &Tag{}
@@ -35,3 +38,83 @@ func TestDeleteAttr(t *testing.T) {
 		t.Error("attr count should be 2 but", len(tag.Attr))
 	}
 }
+
+func TestInsertChild(t *testing.T) {
+	tag := &Tag{}
+	for _, name := range []string{"sheetPr", "separate_tag", "sheetData", "pageMargins"} {
+		tag.Children = append(tag.Children, &Tag{Name: xml.Name{Local: name}})
+	}
+	tag.insertChild(&Tag{Name: xml.Name{Local: "sheetViews"}}, worksheetOrder)
+	tag.insertChild(&Tag{Name: xml.Name{Local: "mergeCells"}}, worksheetOrder)
+	tag.insertChild(&Tag{Name: xml.Name{Local: "tableParts"}}, worksheetOrder)
+	replaced := &Tag{Name: xml.Name{Local: "mergeCells"}}
+	tag.insertChild(replaced, worksheetOrder)
+	expected := []string{"sheetPr", "sheetViews", "separate_tag", "sheetData", "mergeCells", "pageMargins", "tableParts"}
+	if len(tag.Children) != len(expected) {
+		t.Fatal("children count should be", len(expected), "but", len(tag.Children))
+	}
+	for i, name := range expected {
+		if n := tag.Children[i].(*Tag).Name.Local; n != name {
+			t.Error("child", i, "should be", name, "but", n)
+		}
+	}
+	if tag.getChild("mergeCells") != replaced {
+		t.Error("mergeCells should be replaced.")
+	}
+	tag.removeChild("mergeCells")
+	if tag.getChild("mergeCells") != nil {
+		t.Error("mergeCells should be removed.")
+	}
+}
+
+func TestAddChild(t *testing.T) {
+	tag := &Tag{}
+	for _, name := range []string{"sheetData", "conditionalFormatting", "pageMargins"} {
+		tag.Children = append(tag.Children, &Tag{Name: xml.Name{Local: name}})
+	}
+	added := &Tag{Name: xml.Name{Local: "conditionalFormatting"}}
+	tag.addChild(added, worksheetOrder)
+	if len(tag.Children) != 4 || tag.Children[2] != added {
+		t.Error("conditionalFormatting should be added after the same tag.", tag.Children)
+	}
+}
+
+func TestUnmarshalTagPrefix(t *testing.T) {
+	data := `<wsDr xmlns="urn:main" xmlns:r="urn:r" xmlns:s="urn:s" xmlns:x="urn:x">` +
+		`<p:pic xmlns:p="urn:p"><p:blip r:embed="rId1"></p:blip></p:pic>` +
+		`<q:pic xmlns:q="urn:p"><q:blip></q:blip></q:pic>` +
+		`<c:chart xmlns:c="urn:c" c:id="1"></c:chart>` +
+		`<pic></pic></wsDr>`
+	tag := &Tag{}
+	if err := xml.Unmarshal([]byte(data), tag); err != nil {
+		t.Fatal("xml should be unmarshaled.", err.Error())
+	}
+	expected := []string{"p:pic", "q:pic", "c:chart", "pic"}
+	if len(tag.Children) != len(expected) {
+		t.Fatal("children count should be", len(expected), "but", len(tag.Children))
+	}
+	for i, name := range expected {
+		if n := tag.Children[i].(*Tag).Name.Local; n != name {
+			t.Error("child", i, "should be", name, "but", n)
+		}
+	}
+	if n := tag.Children[0].(*Tag).getChild("p:blip"); n == nil {
+		t.Error("prefix declared on the parent should be used.")
+	}
+	if n := tag.Children[1].(*Tag).getChild("q:blip"); n == nil {
+		t.Error("prefix declared on the sibling should not be used.")
+	}
+	if v, err := tag.Children[2].(*Tag).getAttr("c:id"); err != nil || v != "1" {
+		t.Error("prefix declared on the element itself should be used for the attribute.", v)
+	}
+	if list := tag.Children[0].(*Tag).XmlnsList; len(list) != 4 || list[3].Name.Local != "p" {
+		t.Error("namespaces should not be overwritten by siblings.", list)
+	}
+	if list := tag.Children[3].(*Tag).XmlnsList; len(list) != 3 {
+		t.Error("namespaces of siblings should not leak.", list)
+	}
+	b, _ := xml.Marshal(tag)
+	if string(b) != data {
+		t.Error("xml should be marshaled as it was.", string(b))
+	}
+}
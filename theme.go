@@ -1,13 +1,7 @@
 package excl
 
-import (
-	"os"
-	"path/filepath"
-)
-
-func createTheme1(dir string) error {
-	os.MkdirAll(filepath.Join(dir, "xl", "theme"), 0755)
-	f, err := os.Create(filepath.Join(dir, "xl", "theme", "theme1.xml"))
+func createTheme1(storage Storage) error {
+	f, err := storage.Create("xl/theme/theme1.xml")
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,337 @@
+package excl
+
+import (
+	"encoding/xml"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ValidationType 入力規則の種類
+type ValidationType string
+
+const (
+	// ValidationNone 制限しない(入力時メッセージのみ表示する)
+	ValidationNone ValidationType = "none"
+	// ValidationList リストから選択する
+	ValidationList ValidationType = "list"
+	// ValidationWhole 整数
+	ValidationWhole ValidationType = "whole"
+	// ValidationDecimal 小数
+	ValidationDecimal ValidationType = "decimal"
+	// ValidationDate 日付
+	ValidationDate ValidationType = "date"
+	// ValidationTime 時刻
+	ValidationTime ValidationType = "time"
+	// ValidationTextLength 文字列の長さ
+	ValidationTextLength ValidationType = "textLength"
+	// ValidationCustom 数式
+	ValidationCustom ValidationType = "custom"
+)
+
+// ValidationOperator 入力規則の比較演算子
+type ValidationOperator string
+
+const (
+	// ValidationBetween Formula1以上Formula2以下
+	ValidationBetween ValidationOperator = "between"
+	// ValidationNotBetween Formula1からFormula2の範囲外
+	ValidationNotBetween ValidationOperator = "notBetween"
+	// ValidationEqual 等しい
+	ValidationEqual ValidationOperator = "equal"
+	// ValidationNotEqual 等しくない
+	ValidationNotEqual ValidationOperator = "notEqual"
+	// ValidationGreaterThan より大きい
+	ValidationGreaterThan ValidationOperator = "greaterThan"
+	// ValidationLessThan より小さい
+	ValidationLessThan ValidationOperator = "lessThan"
+	// ValidationGreaterThanOrEqual 以上
+	ValidationGreaterThanOrEqual ValidationOperator = "greaterThanOrEqual"
+	// ValidationLessThanOrEqual 以下
+	ValidationLessThanOrEqual ValidationOperator = "lessThanOrEqual"
+)
+
+// ValidationErrorStyle 無効なデータが入力された場合のエラーの種類
+type ValidationErrorStyle string
+
+const (
+	// ValidationStop 入力を中止する
+	ValidationStop ValidationErrorStyle = "stop"
+	// ValidationWarning 警告を表示する
+	ValidationWarning ValidationErrorStyle = "warning"
+	// ValidationInformation 情報を表示する
+	ValidationInformation ValidationErrorStyle = "information"
+)
+
+// DataValidation 入力規則
+type DataValidation struct {
+	// Ref 入力規則の範囲("A2:A100"など)。複数の範囲は空白で区切る。DataValidationsで取得した場合のみ設定される
+	Ref string
+	// Type 入力規則の種類
+	Type ValidationType
+	// Operator 比較演算子(空の場合はbetween)。ListとCustomでは使用しない
+	Operator ValidationOperator
+	// Values リストの値。ListでFormula1が空の場合に使用する
+	Values []string
+	// Formula1 値または数式。Listの場合は"Sheet2!$A$1:$A$10"のような範囲
+	Formula1 string
+	// Formula2 betweenとnotBetweenの上限の値または数式
+	Formula2 string
+	// AllowBlank 空白を無視する
+	AllowBlank bool
+	// HideDropDown リストのドロップダウンを表示しない
+	HideDropDown bool
+	// PromptTitle 入力時メッセージのタイトル
+	PromptTitle string
+	// Prompt 入力時メッセージ
+	Prompt string
+	// ErrorStyle エラーの種類(空の場合はstop)
+	ErrorStyle ValidationErrorStyle
+	// ErrorTitle エラーメッセージのタイトル
+	ErrorTitle string
+	// Error エラーメッセージ
+	Error string
+}
+
+// AddDataValidation add the data validation rule to the range such as "A2:A100"
+// Multiple ranges are separated by spaces such as "A2:A100 C2:C100".
+func (sheet *Sheet) AddDataValidation(ref string, rule DataValidation) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	sqref, err := parseSqref(ref)
+	if err != nil {
+		return err
+	}
+	tag, err := dataValidationTag(rule)
+	if err != nil {
+		return err
+	}
+	tag.setAttr("sqref", sqref)
+	validations := sheet.worksheet.getChild("dataValidations")
+	if validations == nil {
+		validations = &Tag{Name: xml.Name{Local: "dataValidations"}}
+		sheet.worksheet.insertChild(validations, worksheetOrder)
+	}
+	validations.Children = append(validations.Children, tag)
+	validations.setAttr("count", strconv.Itoa(len(dataValidationTags(validations))))
+	return nil
+}
+
+// DataValidations get the data validation rules of the sheet
+func (sheet *Sheet) DataValidations() ([]DataValidation, error) {
+	if !sheet.opened {
+		return nil, errors.New("The sheet is not opened.")
+	}
+	validations := sheet.worksheet.getChild("dataValidations")
+	if validations == nil {
+		return nil, nil
+	}
+	var rules []DataValidation
+	for _, tag := range dataValidationTags(validations) {
+		rules = append(rules, parseDataValidation(tag))
+	}
+	return rules, nil
+}
+
+// RemoveDataValidation remove the data validation rules whose range is the ref
+// To edit a rule, remove it and add the new one.
+func (sheet *Sheet) RemoveDataValidation(ref string) error {
+	if !sheet.opened {
+		return errors.New("The sheet is not opened.")
+	}
+	sqref, err := parseSqref(ref)
+	if err != nil {
+		return err
+	}
+	validations := sheet.worksheet.getChild("dataValidations")
+	if validations == nil {
+		return errors.New("The range [" + ref + "] has no data validation.")
+	}
+	var children []interface{}
+	removed := false
+	for _, child := range validations.Children {
+		if tag, ok := child.(*Tag); ok && tag.Name.Local == "dataValidation" {
+			if s, _ := tag.getAttr("sqref"); s == sqref {
+				removed = true
+				continue
+			}
+		}
+		children = append(children, child)
+	}
+	if !removed {
+		return errors.New("The range [" + ref + "] has no data validation.")
+	}
+	validations.Children = children
+	count := len(dataValidationTags(validations))
+	if count == 0 {
+		sheet.worksheet.removeChild("dataValidations")
+	} else {
+		validations.setAttr("count", strconv.Itoa(count))
+	}
+	return nil
+}
+
+// parseSqref 空白で区切られた範囲を正規化する
+func parseSqref(ref string) (string, error) {
+	var refs []string
+	for _, part := range strings.Fields(ref) {
+		r, err := parseRange(part)
+		if err != nil {
+			return "", err
+		}
+		refs = append(refs, r.String())
+	}
+	if len(refs) == 0 {
+		return "", errors.New("The range [" + ref + "] is invalid.")
+	}
+	return strings.Join(refs, " "), nil
+}
+
+// dataValidationTags dataValidationsタグからdataValidationタグを取得する
+func dataValidationTags(validations *Tag) []*Tag {
+	var tags []*Tag
+	for _, child := range validations.Children {
+		if tag, ok := child.(*Tag); ok && tag.Name.Local == "dataValidation" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// dataValidationTag 入力規則からdataValidationタグを作成する
+func dataValidationTag(rule DataValidation) (*Tag, error) {
+	formula1 := rule.Formula1
+	switch rule.Type {
+	case ValidationList:
+		if formula1 == "" {
+			list, err := listFormula(rule.Values)
+			if err != nil {
+				return nil, err
+			}
+			formula1 = list
+		}
+	case ValidationWhole, ValidationDecimal, ValidationDate, ValidationTime, ValidationTextLength:
+		switch rule.Operator {
+		case "", ValidationBetween, ValidationNotBetween:
+			if rule.Formula2 == "" {
+				return nil, errors.New("Formula2 is required for the between operator.")
+			}
+		case ValidationEqual, ValidationNotEqual, ValidationGreaterThan, ValidationLessThan,
+			ValidationGreaterThanOrEqual, ValidationLessThanOrEqual:
+		default:
+			return nil, errors.New("The validation operator [" + string(rule.Operator) + "] is invalid.")
+		}
+	case ValidationNone, ValidationCustom:
+	default:
+		return nil, errors.New("The validation type [" + string(rule.Type) + "] is invalid.")
+	}
+	if formula1 == "" && rule.Type != ValidationNone {
+		return nil, errors.New("Formula1 is required for the data validation.")
+	}
+	switch rule.ErrorStyle {
+	case "", ValidationStop, ValidationWarning, ValidationInformation:
+	default:
+		return nil, errors.New("The error style [" + string(rule.ErrorStyle) + "] is invalid.")
+	}
+	if len([]rune(rule.PromptTitle)) > 32 || len([]rune(rule.ErrorTitle)) > 32 {
+		return nil, errors.New("The title of the message should be 32 characters or less.")
+	}
+	if len([]rune(rule.Prompt)) > 255 || len([]rune(rule.Error)) > 255 {
+		return nil, errors.New("The message should be 255 characters or less.")
+	}
+
+	tag := &Tag{Name: xml.Name{Local: "dataValidation"}}
+	tag.setAttr("type", string(rule.Type))
+	if rule.ErrorStyle != "" && rule.ErrorStyle != ValidationStop {
+		tag.setAttr("errorStyle", string(rule.ErrorStyle))
+	}
+	if rule.Operator != "" && rule.Operator != ValidationBetween && hasOperator(rule.Type) {
+		tag.setAttr("operator", string(rule.Operator))
+	}
+	if rule.AllowBlank {
+		tag.setAttr("allowBlank", "1")
+	}
+	if rule.HideDropDown {
+		// showDropDownは名前と逆に1の場合にドロップダウンを表示しない
+		tag.setAttr("showDropDown", "1")
+	}
+	tag.setAttr("showInputMessage", "1")
+	tag.setAttr("showErrorMessage", "1")
+	if rule.ErrorTitle != "" {
+		tag.setAttr("errorTitle", rule.ErrorTitle)
+	}
+	if rule.Error != "" {
+		tag.setAttr("error", rule.Error)
+	}
+	if rule.PromptTitle != "" {
+		tag.setAttr("promptTitle", rule.PromptTitle)
+	}
+	if rule.Prompt != "" {
+		tag.setAttr("prompt", rule.Prompt)
+	}
+	if formula1 != "" {
+		tag.Children = append(tag.Children, &Tag{Name: xml.Name{Local: "formula1"}, Children: []interface{}{xml.CharData(formula1)}})
+	}
+	if rule.Formula2 != "" && hasOperator(rule.Type) && (rule.Operator == "" || rule.Operator == ValidationBetween || rule.Operator == ValidationNotBetween) {
+		tag.Children = append(tag.Children, &Tag{Name: xml.Name{Local: "formula2"}, Children: []interface{}{xml.CharData(rule.Formula2)}})
+	}
+	return tag, nil
+}
+
+// hasOperator 比較演算子を使用する種類か確認する
+func hasOperator(t ValidationType) bool {
+	return t != ValidationNone && t != ValidationList && t != ValidationCustom
+}
+
+// listFormula リストの値から"a,b,c"の形式の数式を作成する
+func listFormula(values []string) (string, error) {
+	if len(values) == 0 {
+		return "", errors.New("The values of the list are empty.")
+	}
+	for _, value := range values {
+		if strings.Contains(value, ",") {
+			return "", errors.New("The value [" + value + "] of the list should not contain a comma.")
+		}
+	}
+	list := strings.Join(values, ",")
+	if len([]rune(list)) > 255 {
+		return "", errors.New("The values of the list should be 255 characters or less.")
+	}
+	return `"` + strings.Replace(list, `"`, `""`, -1) + `"`, nil
+}
+
+// parseDataValidation dataValidationタグから入力規則を取得する
+func parseDataValidation(tag *Tag) DataValidation {
+	attr := func(name string) string {
+		v, _ := tag.getAttr(name)
+		return v
+	}
+	rule := DataValidation{
+		Ref:          attr("sqref"),
+		Type:         ValidationType(attr("type")),
+		Operator:     ValidationOperator(attr("operator")),
+		AllowBlank:   attr("allowBlank") == "1" || attr("allowBlank") == "true",
+		HideDropDown: attr("showDropDown") == "1" || attr("showDropDown") == "true",
+		PromptTitle:  attr("promptTitle"),
+		Prompt:       attr("prompt"),
+		ErrorStyle:   ValidationErrorStyle(attr("errorStyle")),
+		ErrorTitle:   attr("errorTitle"),
+		Error:        attr("error"),
+	}
+	if formula := tag.getChild("formula1"); formula != nil {
+		rule.Formula1 = formula.text()
+	}
+	if formula := tag.getChild("formula2"); formula != nil {
+		rule.Formula2 = formula.text()
+	}
+	if rule.Type == ValidationList && len(rule.Formula1) >= 2 && strings.HasPrefix(rule.Formula1, `"`) && strings.HasSuffix(rule.Formula1, `"`) {
+		list := strings.Replace(rule.Formula1[1:len(rule.Formula1)-1], `""`, `"`, -1)
+		rule.Values = strings.Split(list, ",")
+		rule.Formula1 = ""
+	}
+	if rule.Type == "" {
+		rule.Type = ValidationNone
+	}
+	return rule
+}
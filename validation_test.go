@@ -0,0 +1,96 @@
+package excl
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestAddDataValidation(t *testing.T) {
+	workbook, _ := Create(WithStorage(NewMemoryStorage()))
+	defer workbook.Close()
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	err := sheet.AddDataValidation("A2:A100", DataValidation{
+		Type:        ValidationList,
+		Values:      []string{"small", "medium", `"large"`},
+		AllowBlank:  true,
+		PromptTitle: "Size",
+		Prompt:      "Select the size.",
+	})
+	if err != nil {
+		t.Fatal("data validation should be added.", err.Error())
+	}
+	err = sheet.AddDataValidation("C2:C100 E2:E100", DataValidation{
+		Type:       ValidationWhole,
+		Operator:   ValidationGreaterThan,
+		Formula1:   "0",
+		ErrorStyle: ValidationWarning,
+		ErrorTitle: "Quantity",
+		Error:      "Enter a positive number.",
+	})
+	if err != nil {
+		t.Fatal("data validation should be added.", err.Error())
+	}
+	if err = sheet.AddDataValidation("B2:B100", DataValidation{Type: ValidationList, Formula1: "Master!$A$1:$A$10"}); err != nil {
+		t.Error("data validation should be added.", err.Error())
+	}
+	if err = sheet.AddDataValidation("D2", DataValidation{Type: ValidationDate, Formula1: "DATE(2020,1,1)"}); err == nil {
+		t.Error("between operator without Formula2 should be error.")
+	}
+	if err = sheet.AddDataValidation("D2", DataValidation{Type: ValidationList, Values: []string{"a,b"}}); err == nil {
+		t.Error("list value which contains a comma should be error.")
+	}
+	if err = sheet.AddDataValidation("D2", DataValidation{Type: "unknown", Formula1: "1"}); err == nil {
+		t.Error("invalid type should be error.")
+	}
+	sheet.Close()
+
+	f, _ := workbook.storage.Open("xl/worksheets/sheet1.xml")
+	b, _ := ioutil.ReadAll(f)
+	f.Close()
+	expected := `<dataValidations count="3">` +
+		`<dataValidation type="list" allowBlank="1" showInputMessage="1" showErrorMessage="1" promptTitle="Size" prompt="Select the size." sqref="A2:A100"><formula1>&#34;small,medium,&#34;&#34;large&#34;&#34;&#34;</formula1></dataValidation>` +
+		`<dataValidation type="whole" errorStyle="warning" operator="greaterThan" showInputMessage="1" showErrorMessage="1" errorTitle="Quantity" error="Enter a positive number." sqref="C2:C100 E2:E100"><formula1>0</formula1></dataValidation>` +
+		`<dataValidation type="list" showInputMessage="1" showErrorMessage="1" sqref="B2:B100"><formula1>Master!$A$1:$A$10</formula1></dataValidation>` +
+		`</dataValidations>`
+	if !strings.Contains(string(b), "</sheetData>"+expected) {
+		t.Error("dataValidations should be written after sheetData.", string(b))
+	}
+
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	rules, err := sheet.DataValidations()
+	if err != nil || len(rules) != 3 {
+		t.Fatal("data validations should be read.", rules, err)
+	}
+	if rules[0].Ref != "A2:A100" || rules[0].Type != ValidationList || len(rules[0].Values) != 3 || rules[0].Values[2] != `"large"` || !rules[0].AllowBlank || rules[0].Prompt != "Select the size." {
+		t.Error("list validation is invalid.", rules[0])
+	}
+	if rules[1].Ref != "C2:C100 E2:E100" || rules[1].Operator != ValidationGreaterThan || rules[1].Formula1 != "0" || rules[1].ErrorStyle != ValidationWarning {
+		t.Error("whole validation is invalid.", rules[1])
+	}
+	if rules[2].Formula1 != "Master!$A$1:$A$10" || rules[2].Values != nil {
+		t.Error("list validation with range is invalid.", rules[2])
+	}
+
+	rule := rules[1]
+	rule.Formula1 = "10"
+	if err = sheet.RemoveDataValidation(rule.Ref); err != nil {
+		t.Error("data validation should be removed.", err.Error())
+	}
+	if err = sheet.AddDataValidation(rule.Ref, rule); err != nil {
+		t.Error("data validation should be added.", err.Error())
+	}
+	rules, _ = sheet.DataValidations()
+	if len(rules) != 3 || rules[2].Formula1 != "10" {
+		t.Error("data validation should be edited.", rules)
+	}
+	if err = sheet.RemoveDataValidation("Z1"); err == nil {
+		t.Error("range without data validation should be error.")
+	}
+	sheet.RemoveDataValidation("A2:A100")
+	sheet.RemoveDataValidation("B2:B100")
+	sheet.RemoveDataValidation("C2:C100 E2:E100")
+	if sheet.worksheet.getChild("dataValidations") != nil {
+		t.Error("empty dataValidations should be removed.")
+	}
+}
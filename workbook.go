@@ -2,17 +2,17 @@ package excl
 
 import (
 	"archive/zip"
-	"crypto/rand"
-	"encoding/binary"
+	"bytes"
+	"compress/flate"
 	"encoding/xml"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"path"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -23,6 +23,7 @@ import (
 // Workbook はワークブック内の情報を格納する
 type Workbook struct {
 	TempPath      string
+	storage       Storage
 	types         *ContentTypes
 	opened        bool
 	maxSheetID    int
@@ -33,8 +34,42 @@ type Workbook struct {
 	workbookTag   *Tag
 	sheetsTag     *Tag
 	calcPr        *Tag
+	saveOptions   SaveOptions
 }
 
+// Compression zipの圧縮レベル
+type Compression int
+
+const (
+	// CompressionDefault deflateの標準の圧縮レベル
+	CompressionDefault Compression = iota
+	// CompressionStore 圧縮しない
+	CompressionStore
+	// CompressionBestSpeed 速度優先で圧縮する
+	CompressionBestSpeed
+	// CompressionBest 圧縮率優先で圧縮する
+	CompressionBest
+)
+
+// SaveOptions SaveとWriteToの出力方法
+type SaveOptions struct {
+	// Deterministic 更新日時を固定し[Content_Types].xmlを先頭に一定の順序でパーツを出力する
+	Deterministic bool
+	// Compression 圧縮レベル
+	Compression Compression
+}
+
+// workbookOrder workbookの子タグの順序
+var workbookOrder = []string{
+	"fileVersion", "fileSharing", "workbookPr", "workbookProtection", "bookViews",
+	"sheets", "functionGroups", "externalReferences", "definedNames", "calcPr",
+	"oleSize", "customWorkbookViews", "pivotCaches", "smartTagPr", "smartTagTypes",
+	"webPublishing", "fileRecoveryPr", "webPublishObjects", "extLst",
+}
+
+// deterministicTime Deterministicの場合にzipに記録する更新日時
+var deterministicTime = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+
 // WorkbookXML workbook.xmlに記載されている<workbook>タグの中身
 type WorkbookXML struct {
 	XMLName xml.Name  `xml:"workbook"`
@@ -48,36 +83,35 @@ type sheetsXML struct {
 }
 
 // Create 新しくワークブックを作成する
-func Create() (*Workbook, error) {
-	dir, err := ioutil.TempDir("", "excl_"+strings.Replace(time.Now().Format("20060102030405.000"), ".", "", 1))
+func Create(opts ...Option) (*Workbook, error) {
+	o, err := newOptions(opts)
 	if err != nil {
 		return nil, err
 	}
-	workbook := &Workbook{TempPath: dir}
+	workbook := newWorkbook(o.storage)
 	defer func() {
 		if !workbook.opened {
 			workbook.Close()
+			o.storage.Close()
 		}
 	}()
-	if err := createContentTypes(dir); err != nil {
-		return nil, err
-	}
-	if err := createRels(dir); err != nil {
+	storage := o.storage
+	if err := createContentTypes(storage); err != nil {
 		return nil, err
 	}
-	if err := createWorkbook(dir); err != nil {
+	if err := createRels(storage); err != nil {
 		return nil, err
 	}
-	if err := createWorkbookRels(dir); err != nil {
+	if err := createWorkbook(storage); err != nil {
 		return nil, err
 	}
-	if err := createStyles(dir); err != nil {
+	if err := createWorkbookRels(storage); err != nil {
 		return nil, err
 	}
-	if err := createTheme1(dir); err != nil {
+	if err := createStyles(storage); err != nil {
 		return nil, err
 	}
-	if err := os.Mkdir(filepath.Join(dir, "xl", "worksheets"), 0755); err != nil {
+	if err := createTheme1(storage); err != nil {
 		return nil, err
 	}
 	if err := workbook.setInfo(); err != nil {
@@ -88,29 +122,54 @@ func Create() (*Workbook, error) {
 }
 
 // Open Excelファイルを開く
-func Open(path string) (*Workbook, error) {
+func Open(path string, opts ...Option) (*Workbook, error) {
 	if !isFileExist(path) {
 		return nil, errors.New("Excel file does not exist.")
 	}
-	dir, err := ioutil.TempDir("", "excl"+strings.Replace(time.Now().Format("20060102030405"), ".", "", 1))
+	r, err := zip.OpenReader(path)
 	if err != nil {
 		return nil, err
 	}
-	workbook := &Workbook{TempPath: dir}
-	if err := unzip(path, dir); err != nil {
+	defer r.Close()
+	return openZip(&r.Reader, opts)
+}
+
+// OpenReader open an excel file from io.ReaderAt
+func OpenReader(r io.ReaderAt, size int64, opts ...Option) (*Workbook, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
 		return nil, err
 	}
+	return openZip(zr, opts)
+}
 
+// OpenBytes open an excel file from bytes
+func OpenBytes(b []byte, opts ...Option) (*Workbook, error) {
+	return OpenReader(bytes.NewReader(b), int64(len(b)), opts...)
+}
+
+// openZip zipの内容をストレージに展開してワークブックを開く
+func openZip(r *zip.Reader, opts []Option) (*Workbook, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	storage := o.storage
+	workbook := newWorkbook(storage)
 	defer func() {
 		if !workbook.opened {
 			workbook.Close()
+			storage.Close()
 		}
 	}()
+	if err := unzipReader(r, storage); err != nil {
+		return nil, err
+	}
 
-	if !isFileExist(filepath.Join(dir, "[Content_Types].xml")) ||
-		!isFileExist(filepath.Join(dir, "xl", "workbook.xml")) ||
-		!isFileExist(filepath.Join(dir, "xl", "_rels", "workbook.xml.rels")) ||
-		!isFileExist(filepath.Join(dir, "xl", "styles.xml")) {
+	if !storage.Exists(contentTypesName) ||
+		!storage.Exists("xl/workbook.xml") ||
+		!storage.Exists("xl/_rels/workbook.xml.rels") ||
+		!storage.Exists("xl/styles.xml") {
 		return nil, fmt.Errorf("this excel file is corrupt")
 	}
 	err = workbook.setInfo()
@@ -126,9 +185,47 @@ func (workbook *Workbook) Save(path string) error {
 	if workbook == nil || !workbook.opened {
 		return nil
 	}
+	defer workbook.storage.Close()
+	if err := workbook.closeParts(); err != nil {
+		return err
+	}
+	if path != "" {
+		names, err := workbook.storage.List()
+		if err != nil {
+			return err
+		}
+		return createZip(path, workbook.storage, names, workbook.saveOptions)
+	}
+	return nil
+}
+
+// SetSaveOptions SaveとWriteToの出力方法を設定する
+func (workbook *Workbook) SetSaveOptions(opts SaveOptions) {
+	workbook.saveOptions = opts
+}
+
+// WriteTo write the workbook as xlsx to w and close the workbook
+func (workbook *Workbook) WriteTo(w io.Writer) (int64, error) {
+	if workbook == nil || !workbook.opened {
+		return 0, errors.New("The workbook is not opened.")
+	}
+	defer workbook.storage.Close()
+	if err := workbook.closeParts(); err != nil {
+		return 0, err
+	}
+	names, err := workbook.storage.List()
+	if err != nil {
+		return 0, err
+	}
+	cw := &countWriter{w: w}
+	err = writeZip(cw, workbook.storage, names, workbook.saveOptions)
+	return cw.n, err
+}
+
+// closeParts 各パーツを閉じてファイルに書き出す
+func (workbook *Workbook) closeParts() error {
 	var err, sheetErr, ssErr, relsErr, stylesErr, typesErr error
-	var f *os.File
-	defer os.RemoveAll(workbook.TempPath)
+	var f File
 	for _, sheet := range workbook.sheets {
 		tempErr := sheet.Close()
 		if sheetErr == nil && tempErr != nil {
@@ -136,6 +233,9 @@ func (workbook *Workbook) Save(path string) error {
 		}
 	}
 	ssErr = workbook.SharedStrings.Close()
+	if ssErr == nil {
+		ssErr = workbook.SharedStrings.Err()
+	}
 	relsErr = workbook.workbookRels.Close()
 	stylesErr = workbook.Styles.Close()
 	typesErr = workbook.types.Close()
@@ -151,7 +251,7 @@ func (workbook *Workbook) Save(path string) error {
 	} else if typesErr != nil {
 		return typesErr
 	}
-	f, err = os.Create(filepath.Join(workbook.TempPath, "xl", "workbook.xml"))
+	f, err = workbook.storage.Create("xl/workbook.xml")
 	if err != nil {
 		return err
 	}
@@ -160,11 +260,7 @@ func (workbook *Workbook) Save(path string) error {
 	if err = xml.NewEncoder(f).Encode(workbook.workbookTag); err != nil {
 		return err
 	}
-	f.Close()
-	if path != "" {
-		createZip(path, getFiles(workbook.TempPath), workbook.TempPath)
-	}
-	return nil
+	return f.Close()
 }
 
 // Close 操作中のブックを閉じる(保存はしない)
@@ -175,13 +271,8 @@ func (workbook *Workbook) Close() error {
 // OpenSheet Open specified sheet
 // if there is no specified sheet then create new sheet
 func (workbook *Workbook) OpenSheet(name string) (*Sheet, error) {
-	compName := strings.ToLower(string(norm.NFKC.Bytes([]byte(name))))
-	for _, sheet := range workbook.sheets {
-		sheetName := strings.ToLower(string(norm.NFKC.Bytes([]byte(sheet.xml.Name))))
-		if sheetName != compName {
-			continue
-		}
-		err := sheet.Open(workbook.TempPath)
+	if sheet := workbook.getSheet(name); sheet != nil {
+		err := sheet.open(workbook.storage)
 		if err != nil {
 			return nil, err
 		}
@@ -196,7 +287,8 @@ func (workbook *Workbook) OpenSheet(name string) (*Sheet, error) {
 	sheet := newSheet(name, workbook.maxSheetID, rid, target)
 	sheet.sharedStrings = workbook.SharedStrings
 	sheet.Styles = workbook.Styles
-	if err := sheet.Create(workbook.TempPath); err != nil {
+	sheet.workbook = workbook
+	if err := sheet.create(workbook.storage); err != nil {
 		return nil, err
 	}
 	workbook.sheets = append(workbook.sheets, sheet)
@@ -204,6 +296,39 @@ func (workbook *Workbook) OpenSheet(name string) (*Sheet, error) {
 	return sheet, nil
 }
 
+// getSheet get the sheet which has the name
+// The name is compared after NFKC normalization and case folding.
+func (workbook *Workbook) getSheet(name string) *Sheet {
+	compName := strings.ToLower(string(norm.NFKC.Bytes([]byte(name))))
+	for _, sheet := range workbook.sheets {
+		sheetName := strings.ToLower(string(norm.NFKC.Bytes([]byte(sheet.xml.Name))))
+		if sheetName == compName {
+			return sheet
+		}
+	}
+	return nil
+}
+
+// StreamRows read rows of the sheet one by one without opening the sheet
+// Iteration stops when fn returns an error and the error is returned.
+func (workbook *Workbook) StreamRows(name string, fn func(*Row) error) error {
+	sheet := workbook.getSheet(name)
+	if sheet == nil {
+		return errors.New("The sheet [" + name + "] does not exist.")
+	}
+	it, err := sheet.RowIterator()
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	for it.Next() {
+		if err = fn(it.Row()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
 // SetForceFormulaRecalculation set fullCalcOnLoad attribute to calcPr tag.
 // When this excel file is opened, all calculation fomula will be recalculated.
 func (workbook *Workbook) SetForceFormulaRecalculation(flg bool) {
@@ -266,22 +391,31 @@ func createSheetTag(name string, rid string, sheetID int) *Tag {
 	return tag
 }
 
+// newWorkbook create a workbook which uses the storage
+func newWorkbook(storage Storage) *Workbook {
+	workbook := &Workbook{storage: storage}
+	if s, ok := storage.(*dirStorage); ok {
+		workbook.TempPath = s.dir
+	}
+	return workbook
+}
+
 // setInfo xlsx情報を読み込みセットする
 func (workbook *Workbook) setInfo() error {
 	var err error
-	workbook.types, err = OpenContentTypes(workbook.TempPath)
+	workbook.types, err = openContentTypes(workbook.storage)
 	if err != nil {
 		return err
 	}
-	workbook.Styles, err = OpenStyles(workbook.TempPath)
+	workbook.Styles, err = openStyles(workbook.storage)
 	if err != nil {
 		return err
 	}
-	workbook.workbookRels, err = OpenWorkbookRels(workbook.TempPath)
+	workbook.workbookRels, err = openWorkbookRels(workbook.storage)
 	if err != nil {
 		return err
 	}
-	workbook.SharedStrings, err = OpenSharedStrings(workbook.TempPath)
+	workbook.SharedStrings, err = openSharedStrings(workbook.storage)
 	if err != nil {
 		return err
 	}
@@ -296,10 +430,8 @@ func (workbook *Workbook) setInfo() error {
 }
 
 // createWorkbook workbook.xmlファイルを作成する
-func createWorkbook(dir string) error {
-	os.Mkdir(filepath.Join(dir, "xl"), 0755)
-	path := filepath.Join(dir, "xl", "workbook.xml")
-	f, err := os.Create(path)
+func createWorkbook(storage Storage) error {
+	f, err := storage.Create("xl/workbook.xml")
 	if err != nil {
 		return err
 	}
@@ -314,10 +446,8 @@ func createWorkbook(dir string) error {
 }
 
 // createRels .relsファイルを作成する
-func createRels(dir string) error {
-	os.Mkdir(filepath.Join(dir, "_rels"), 0755)
-	path := filepath.Join(dir, "_rels", ".rels")
-	f, err := os.Create(path)
+func createRels(storage Storage) error {
+	f, err := storage.Create("_rels/.rels")
 	if err != nil {
 		return err
 	}
@@ -332,8 +462,8 @@ func createRels(dir string) error {
 
 // openWorkbook open workbook.xml and set workbook information
 func (workbook *Workbook) openWorkbook() error {
-	workbookPath := filepath.Join(workbook.TempPath, "xl", "workbook.xml")
-	f, err := os.Open(workbookPath)
+	workbookPath := "xl/workbook.xml"
+	f, err := workbook.storage.Open(workbookPath)
 	if err != nil {
 		return err
 	}
@@ -354,6 +484,8 @@ func (workbook *Workbook) openWorkbook() error {
 				Styles:        workbook.Styles,
 				sharedStrings: workbook.SharedStrings,
 				target:        target,
+				storage:       workbook.storage,
+				workbook:      workbook,
 			})
 		sheetID, _ := strconv.Atoi(sheet.SheetID)
 		if workbook.maxSheetID < sheetID {
@@ -361,7 +493,9 @@ func (workbook *Workbook) openWorkbook() error {
 		}
 	}
 	tag := &Tag{}
-	f, _ = os.Open(workbookPath)
+	if f, err = workbook.storage.Open(workbookPath); err != nil {
+		return err
+	}
 	defer f.Close()
 	if err = xml.NewDecoder(f).Decode(tag); err != nil {
 		return err
@@ -385,21 +519,6 @@ func (workbook *Workbook) setWorkbookInfo() {
 	}
 }
 
-// getFiles dir以下に存在するファイルの一覧を取得する
-func getFiles(dir string) []string {
-	fileList := []string{}
-	files, _ := ioutil.ReadDir(dir)
-	for _, f := range files {
-		if f.IsDir() == true {
-			list := getFiles(path.Join(dir, f.Name()))
-			fileList = append(fileList, list...)
-			continue
-		}
-		fileList = append(fileList, path.Join(dir, f.Name()))
-	}
-	return fileList
-}
-
 // unzip unzip excel file
 func unzip(src, dest string) error {
 	r, err := zip.OpenReader(src)
@@ -408,61 +527,174 @@ func unzip(src, dest string) error {
 	}
 	defer r.Close()
 	os.MkdirAll(dest, 0755)
+	return unzipReader(&r.Reader, newDirStorage(dest))
+}
 
+// unzipReader zipの内容をストレージに展開する
+func unzipReader(r *zip.Reader, storage Storage) error {
 	for _, f := range r.File {
-		rc, err := f.Open()
-		if err != nil {
-			return err
+		name := path.Clean(f.Name)
+		if name == ".." || strings.HasPrefix(name, "../") || strings.HasPrefix(name, "/") {
+			return errors.New("The file name [" + f.Name + "] is invalid.")
 		}
-		defer rc.Close()
-
-		path := filepath.Join(dest, f.Name)
 		if f.FileInfo().IsDir() {
-			os.MkdirAll(path, 0755)
-		} else {
-			os.MkdirAll(filepath.Dir(path), 0755)
-			to, err := os.OpenFile(
-				path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0755)
-			if err != nil {
-				return err
-			}
-			defer to.Close()
-			_, err = io.Copy(to, rc)
-			if err != nil {
-				return err
-			}
-			to.Close()
+			continue
+		}
+		if err := unzipFile(f, storage, name); err != nil {
+			return err
 		}
 	}
 	return nil
 }
 
-func createZip(zipPath string, fileList []string, replace string) {
-	var zipfile *os.File
-	var err error
-	if zipfile, err = os.Create(zipPath); err != nil {
-		log.Fatalln(err)
-	}
-	defer zipfile.Close()
-	w := zip.NewWriter(zipfile)
-	for _, file := range fileList {
-		read, err := os.Open(file)
-		defer read.Close()
+// unzipFile zipの1ファイルをストレージのnameに展開する
+func unzipFile(f *zip.File, storage Storage, name string) error {
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	to, err := storage.Create(name)
+	if err != nil {
+		rc.Close()
+		return err
+	}
+	if _, err = io.Copy(to, rc); err != nil {
+		to.Close()
+		rc.Close()
+		return err
+	}
+	if err = to.Close(); err != nil {
+		rc.Close()
+		return err
+	}
+	return rc.Close()
+}
+
+// createZip パーツをzipPathにxlsxとして保存する
+// 同じディレクトリの一時ファイルに書き込んでから置き換えるため失敗しても既存のファイルは壊れない
+func createZip(zipPath string, storage Storage, names []string, opts SaveOptions) error {
+	zipfile, err := createTempFile(filepath.Dir(zipPath), "."+filepath.Base(zipPath)+".")
+	if err != nil {
+		return err
+	}
+	tempPath := zipfile.Name()
+	defer func() {
 		if err != nil {
-			fmt.Println(err)
+			zipfile.Close()
+			os.Remove(tempPath)
+		}
+	}()
+	// 既存のファイルがある場合はその権限を引き継ぐ
+	if stat, statErr := os.Stat(zipPath); statErr == nil {
+		if err = zipfile.Chmod(stat.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+	if err = writeZip(zipfile, storage, names, opts); err != nil {
+		return err
+	}
+	if err = zipfile.Sync(); err != nil {
+		return err
+	}
+	if err = zipfile.Close(); err != nil {
+		return err
+	}
+	err = os.Rename(tempPath, zipPath)
+	return err
+}
+
+// createTempFile dirに一時ファイルを作成する
+// os.Createと同じく0666でumaskが適用された権限になる
+func createTempFile(dir string, prefix string) (*os.File, error) {
+	seed := time.Now().UnixNano()
+	for i := int64(0); i < 10000; i++ {
+		name := filepath.Join(dir, prefix+strconv.FormatInt(seed+i, 36))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) {
 			continue
 		}
-		f, err := w.Create(strings.Replace(file, replace+"/", "", 1))
+		return f, err
+	}
+	return nil, errors.New("The temporary file cannot be created in [" + dir + "].")
+}
+
+// writeZip ストレージのパーツをzip形式でwに書き込む
+func writeZip(w io.Writer, storage Storage, names []string, opts SaveOptions) error {
+	zw := zip.NewWriter(w)
+	method := zip.Deflate
+	switch opts.Compression {
+	case CompressionStore:
+		method = zip.Store
+	case CompressionBestSpeed:
+		registerCompressor(zw, flate.BestSpeed)
+	case CompressionBest:
+		registerCompressor(zw, flate.BestCompression)
+	}
+	modified := time.Now()
+	if opts.Deterministic {
+		modified = deterministicTime
+		names = sortParts(names)
+	}
+	for _, name := range names {
+		read, err := storage.Open(name)
 		if err != nil {
-			fmt.Println(err)
-			continue
+			return err
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: method, Modified: modified})
+		if err != nil {
+			read.Close()
+			return err
 		}
 		if _, err = io.Copy(f, read); err != nil {
-			fmt.Println(err)
-			continue
+			read.Close()
+			return err
+		}
+		if err = read.Close(); err != nil {
+			return err
 		}
 	}
-	w.Close()
+	return zw.Close()
+}
+
+// registerCompressor deflateの圧縮レベルを設定する
+func registerCompressor(zw *zip.Writer, level int) {
+	zw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	})
+}
+
+// sortParts [Content_Types].xml、_rels/.relsを先頭にしてパーツ名を並べ替える
+func sortParts(names []string) []string {
+	priority := func(name string) int {
+		switch name {
+		case contentTypesName:
+			return 0
+		case "_rels/.rels":
+			return 1
+		}
+		return 2
+	}
+	sorted := append([]string{}, names...)
+	sort.Slice(sorted, func(i, j int) bool {
+		pi, pj := priority(sorted[i]), priority(sorted[j])
+		if pi != pj {
+			return pi < pj
+		}
+		return sorted[i] < sorted[j]
+	})
+	return sorted
+}
+
+// countWriter 書き込んだバイト数を数えるWriter
+type countWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countWriter) Write(b []byte) (int, error) {
+	n, err := cw.w.Write(b)
+	cw.n += int64(n)
+	return n, err
 }
 
 // isFileExist ファイルの存在確認
@@ -482,9 +714,3 @@ func isDirExist(dirname string) bool {
 	}
 	return stat.IsDir()
 }
-
-func random() string {
-	var n uint64
-	binary.Read(rand.Reader, binary.LittleEndian, &n)
-	return strconv.FormatUint(n, 36)
-}
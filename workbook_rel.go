@@ -4,18 +4,15 @@ import (
 	"encoding/xml"
 	"errors"
 	"io/ioutil"
-	"os"
-	"path/filepath"
 	"regexp"
 	"strconv"
-	"strings"
-	"time"
 )
 
 // WorkbookRels workbook.xml.relの情報をもつ構造体
 type WorkbookRels struct {
-	rels *Relationships
-	path string
+	rels    *Relationships
+	storage Storage
+	path    string
 }
 
 // Relationships Relationshipsタグの情報
@@ -30,13 +27,12 @@ type relationship struct {
 	ID      string   `xml:"Id,attr"`
 	Type    string   `xml:"Type,attr"`
 	Target  string   `xml:"Target,attr"`
+	Mode    string   `xml:"TargetMode,attr,omitempty"`
 }
 
 // createWorkbookRels workbook.xml.relsファイルを作成する
-func createWorkbookRels(dir string) error {
-	os.Mkdir(filepath.Join(dir, "xl", "_rels"), 0755)
-	path := filepath.Join(dir, "xl", "_rels", "workbook.xml.rels")
-	f, err := os.Create(path)
+func createWorkbookRels(storage Storage) error {
+	f, err := storage.Create("xl/_rels/workbook.xml.rels")
 	if err != nil {
 		return err
 	}
@@ -52,11 +48,16 @@ func createWorkbookRels(dir string) error {
 
 // OpenWorkbookRels open workbook.xml.rels
 func OpenWorkbookRels(dir string) (*WorkbookRels, error) {
-	path := filepath.Join(dir, "xl", "_rels", "workbook.xml.rels")
-	if !isFileExist(path) {
+	return openWorkbookRels(newDirStorage(dir))
+}
+
+// openWorkbookRels open workbook.xml.rels in the storage
+func openWorkbookRels(storage Storage) (*WorkbookRels, error) {
+	path := "xl/_rels/workbook.xml.rels"
+	if !storage.Exists(path) {
 		return nil, errors.New("The workbook.xml.rels is not exists.")
 	}
-	f, err := os.Open(path)
+	f, err := storage.Open(path)
 	if err != nil {
 		return nil, err
 	}
@@ -70,7 +71,7 @@ func OpenWorkbookRels(dir string) (*WorkbookRels, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &WorkbookRels{rels: rels, path: path}, nil
+	return &WorkbookRels{rels: rels, storage: storage, path: path}, nil
 }
 
 // Close close workbook.xml.rels
@@ -78,7 +79,10 @@ func (wbr *WorkbookRels) Close() error {
 	if wbr == nil {
 		return nil
 	}
-	f, err := os.Create(wbr.path)
+	if wbr.storage == nil {
+		return errors.New("The storage of workbook.xml.rels is not set.")
+	}
+	f, err := wbr.storage.Create(wbr.path)
 	if err != nil {
 		return err
 	}
@@ -92,6 +96,20 @@ func (wbr *WorkbookRels) Close() error {
 	return nil
 }
 
+// nextID get an unused relationship id
+func (rels *Relationships) nextID() string {
+	used := map[string]bool{}
+	for _, rel := range rels.Rels {
+		used[rel.ID] = true
+	}
+	for n := len(rels.Rels) + 1; ; n++ {
+		id := "rId" + strconv.Itoa(n)
+		if !used[id] {
+			return id
+		}
+	}
+}
+
 // addSharedStrings add sharedStrings.xml information
 func (wbr *WorkbookRels) addSharedStrings() string {
 	for _, rel := range wbr.rels.Rels {
@@ -103,7 +121,7 @@ func (wbr *WorkbookRels) addSharedStrings() string {
 		XMLName: xml.Name{Local: "Relationship"},
 		Target:  "sharedStrings.xml",
 		Type:    "http://schemas.openxmlformats.org/officeDocument/2006/relationships/sharedStrings",
-		ID:      strings.Replace(time.Now().Format("rId060102030405.000"), ".", "", 1) + random(),
+		ID:      wbr.rels.nextID(),
 	}
 	wbr.rels.Rels = append(wbr.rels.Rels, rel)
 	return rel.ID
@@ -115,7 +133,7 @@ func (wbr *WorkbookRels) addSheet(name string) string {
 		XMLName: xml.Name{Local: "Relationship"},
 		Target:  "worksheets/" + name,
 		Type:    "http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet",
-		ID:      strings.Replace(time.Now().Format("rId060102030405.000"), ".", "", 1) + random(),
+		ID:      wbr.rels.nextID(),
 	}
 	wbr.rels.Rels = append(wbr.rels.Rels, rel)
 	return rel.ID
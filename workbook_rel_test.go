@@ -52,7 +52,8 @@ func TestAddSheetWorkbookRels(t *testing.T) {
 	if err == nil {
 		t.Error("close error should be happen.")
 	}
-	wbr.path = filepath.Join("temp", "workbook.xml.rels")
+	wbr.storage = newDirStorage("temp")
+	wbr.path = "workbook.xml.rels"
 	defer os.Remove(filepath.Join("temp", "workbook.xml.rels"))
 	err = wbr.Close()
 	if err != nil {
@@ -73,7 +74,8 @@ func TestCloseWorkbookRels(t *testing.T) {
 		t.Error("error should be happen.")
 	}
 
-	wbr.path = "temp/rels.xml"
+	wbr.storage = newDirStorage("temp")
+	wbr.path = "rels.xml"
 	if err = wbr.Close(); err != nil {
 		t.Error("error should not be happen.", err.Error())
 	}
@@ -2,6 +2,9 @@ package excl
 
 import (
 	"archive/zip"
+	"bytes"
+	"errors"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"testing"
@@ -14,7 +17,9 @@ func createCurruputXLSX(from string, to string, delfile string) {
 	if delfile != "" {
 		os.Remove(filepath.Join("temp/output", delfile))
 	}
-	createZip(to, getFiles("temp/output"), "temp/output")
+	s := newDirStorage("temp/output")
+	names, _ := s.List()
+	createZip(to, s, names, SaveOptions{})
 }
 
 func TestCreateWorkbook(t *testing.T) {
@@ -91,7 +96,7 @@ func TestOpenWorkbook(t *testing.T) {
 	}
 	workbook.Close()
 
-	createZip("temp/empty.xlsx", nil, "")
+	createZip("temp/empty.xlsx", nil, nil, SaveOptions{})
 	defer os.Remove("temp/empty.xlsx")
 	if workbook, err = Open("temp/empty.xlsx"); err == nil {
 		t.Error("workbook should not be opened beacause excel file is not zip file.")
@@ -114,10 +119,128 @@ func TestOpenWorkbook(t *testing.T) {
 
 }
 
+func TestOpenBytes(t *testing.T) {
+	b, _ := ioutil.ReadFile("temp/test.xlsx")
+	workbook, err := OpenBytes(b)
+	if err != nil {
+		t.Fatal("workbook should be opened.", err.Error())
+	}
+	sheet, _ := workbook.OpenSheet("Sheet1")
+	sheet.GetRow(1).SetString("hello", 1)
+	sheet.Close()
+	var buf bytes.Buffer
+	n, err := workbook.WriteTo(&buf)
+	if err != nil {
+		t.Error("workbook should be written.", err.Error())
+	}
+	if n != int64(buf.Len()) {
+		t.Error("written size should be", buf.Len(), "but", n)
+	}
+	if isDirExist(workbook.TempPath) {
+		t.Error("temp directory should be removed.")
+	}
+	if _, err = workbook.WriteTo(&buf); err == nil {
+		t.Error("closed workbook should not be written.")
+	}
+
+	workbook, err = OpenReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal("workbook should be opened.", err.Error())
+	}
+	defer workbook.Close()
+	sheet, _ = workbook.OpenSheet("Sheet1")
+	if v, _ := sheet.GetRow(1).GetCell(1).GetString(); v != "hello" {
+		t.Error("value should be hello but", v)
+	}
+
+	if _, err = OpenBytes([]byte("not zip")); err == nil {
+		t.Error("workbook should not be opened because data is not zip.")
+	}
+}
+
+func TestUnzipInvalidPath(t *testing.T) {
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	z.Create("../evil.txt")
+	z.Close()
+	r, _ := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	os.Mkdir("temp/out", 0755)
+	defer os.RemoveAll("temp/out")
+	if err := unzipReader(r, newDirStorage("temp/out")); err == nil {
+		t.Error("file outside of directory should not be extracted.")
+	}
+	if isFileExist("temp/evil.txt") {
+		os.Remove("temp/evil.txt")
+		t.Error("evil.txt should not be created.")
+	}
+}
+
+// failCloseStorage 作成したファイルのCloseが失敗するストレージ
+type failCloseStorage struct {
+	Storage
+}
+
+type failCloseFile struct {
+	File
+}
+
+func (s *failCloseStorage) Create(name string) (File, error) {
+	f, err := s.Storage.Create(name)
+	return &failCloseFile{f}, err
+}
+
+func (f *failCloseFile) Close() error {
+	f.File.Close()
+	return errors.New("close failed")
+}
+
+func TestUnzipCloseError(t *testing.T) {
+	var buf bytes.Buffer
+	z := zip.NewWriter(&buf)
+	w, _ := z.Create("xl/workbook.xml")
+	w.Write([]byte("<workbook></workbook>"))
+	z.Close()
+	r, _ := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err := unzipReader(r, &failCloseStorage{NewMemoryStorage()}); err == nil {
+		t.Error("close error should be returned.")
+	}
+	storage := NewMemoryStorage()
+	if err := unzipReader(r, storage); err != nil || !storage.Exists("xl/workbook.xml") {
+		t.Error("file should be extracted.", err)
+	}
+}
+
+// failOpenCloseStorage 開いたファイルのCloseが失敗するストレージ
+type failOpenCloseStorage struct {
+	Storage
+}
+
+func (s *failOpenCloseStorage) Open(name string) (File, error) {
+	f, err := s.Storage.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &failCloseFile{f}, nil
+}
+
+func TestWriteZipCloseError(t *testing.T) {
+	storage := NewMemoryStorage()
+	f, _ := storage.Create("xl/workbook.xml")
+	f.WriteString("<workbook></workbook>")
+	f.Close()
+	var buf bytes.Buffer
+	if err := writeZip(&buf, &failOpenCloseStorage{storage}, []string{"xl/workbook.xml"}, SaveOptions{}); err == nil {
+		t.Error("close error should be returned.")
+	}
+	buf.Reset()
+	if err := writeZip(&buf, storage, []string{"xl/workbook.xml"}, SaveOptions{}); err != nil {
+		t.Error("zip should be written.", err)
+	}
+}
+
 func TestOpenWorkbookXML(t *testing.T) {
 	var err error
-	workbook := &Workbook{TempPath: ""}
-	workbook.TempPath = ""
+	workbook := newWorkbook(newDirStorage("temp/workbook"))
 
 	if err = workbook.openWorkbook(); err == nil {
 		t.Error("workbook.xml should not be opened because workbook.xml does not exist.")
@@ -134,7 +257,6 @@ func TestOpenWorkbookXML(t *testing.T) {
 	f1.WriteString("<workbook><sheets><sheet></sheet><sheet></sheet></sheets></workbook>")
 	f1.Close()
 
-	workbook.TempPath = "temp/workbook"
 	err = workbook.openWorkbook()
 	if err != nil {
 		t.Error("workbook.xml should be opened. error[", err.Error(), "]")
@@ -167,17 +289,18 @@ func TestOpenSheet(t *testing.T) {
 func TestSetInfo(t *testing.T) {
 	os.Mkdir("temp/out", 0755)
 	defer os.RemoveAll("temp/out")
-	workbook := &Workbook{TempPath: "temp/out"}
+	storage := newDirStorage("temp/out")
+	workbook := newWorkbook(storage)
 	err := workbook.setInfo()
 	if err == nil {
 		t.Error("[Content_Types].xml should not be opened.")
 	}
-	createContentTypes("temp/out")
+	createContentTypes(storage)
 	err = workbook.setInfo()
 	if err == nil {
 		t.Error("workbook.xml should not be opened.")
 	}
-	createWorkbook("temp/out")
+	createWorkbook(storage)
 	f, _ := os.Create(filepath.Join("temp/out/xl/sharedStrings.xml"))
 	f.Close()
 	err = workbook.setInfo()
@@ -257,3 +380,95 @@ func TestHideSheet(t *testing.T) {
 	}
 	workbook.Close()
 }
+
+func TestCreateZipAtomic(t *testing.T) {
+	if err := createZip("temp/nopath/new.xlsx", nil, nil, SaveOptions{}); err == nil {
+		t.Error("zip should not be created in the directory which does not exist.")
+	}
+	ioutil.WriteFile("temp/keep.xlsx", []byte("keep"), 0644)
+	defer os.Remove("temp/keep.xlsx")
+	if err := createZip("temp/keep.xlsx", NewMemoryStorage(), []string{"xl/nothing.xml"}, SaveOptions{}); err == nil {
+		t.Error("zip should not be created because the part does not exist.")
+	}
+	if b, _ := ioutil.ReadFile("temp/keep.xlsx"); string(b) != "keep" {
+		t.Error("existing file should not be changed.")
+	}
+	files, _ := filepath.Glob("temp/.keep.xlsx.*")
+	if len(files) != 0 {
+		t.Error("temporary file should be removed.", files)
+	}
+
+	workbook, _ := Create()
+	workbook.OpenSheet("Sheet1")
+	if err := workbook.Save("temp/nopath/new.xlsx"); err == nil {
+		t.Error("save error should be returned.")
+	}
+}
+
+func TestCreateZipMode(t *testing.T) {
+	// 新しいファイルはos.Createと同じ権限になる
+	probe, _ := os.Create("temp/probe.txt")
+	probe.Close()
+	defer os.Remove("temp/probe.txt")
+	expected, _ := os.Stat("temp/probe.txt")
+	defer os.Remove("temp/mode.xlsx")
+	if err := createZip("temp/mode.xlsx", NewMemoryStorage(), nil, SaveOptions{}); err != nil {
+		t.Fatal("zip should be created.", err.Error())
+	}
+	if stat, _ := os.Stat("temp/mode.xlsx"); stat.Mode().Perm() != expected.Mode().Perm() {
+		t.Error("mode should be", expected.Mode().Perm(), "but", stat.Mode().Perm())
+	}
+	// 既存のファイルの権限は引き継ぐ
+	os.Chmod("temp/mode.xlsx", 0600)
+	if err := createZip("temp/mode.xlsx", NewMemoryStorage(), nil, SaveOptions{}); err != nil {
+		t.Fatal("zip should be created.", err.Error())
+	}
+	if stat, _ := os.Stat("temp/mode.xlsx"); stat.Mode().Perm() != 0600 {
+		t.Error("mode should be kept but", stat.Mode().Perm())
+	}
+}
+
+func TestSaveOptions(t *testing.T) {
+	write := func(opts SaveOptions) []byte {
+		workbook, _ := Create(WithStorage(NewMemoryStorage()))
+		workbook.SetSaveOptions(opts)
+		sheet, _ := workbook.OpenSheet("Sheet1")
+		sheet.GetRow(1).SetString("hello", 1)
+		sheet.Close()
+		var buf bytes.Buffer
+		if _, err := workbook.WriteTo(&buf); err != nil {
+			t.Fatal("workbook should be written.", err.Error())
+		}
+		return buf.Bytes()
+	}
+	b1 := write(SaveOptions{Deterministic: true})
+	b2 := write(SaveOptions{Deterministic: true})
+	if !bytes.Equal(b1, b2) {
+		t.Error("deterministic output should be same.")
+	}
+	r, _ := zip.NewReader(bytes.NewReader(b1), int64(len(b1)))
+	if r.File[0].Name != "[Content_Types].xml" || r.File[1].Name != "_rels/.rels" {
+		t.Error("[Content_Types].xml and _rels/.rels should be first but", r.File[0].Name, r.File[1].Name)
+	}
+	for _, f := range r.File {
+		if !f.Modified.Equal(deterministicTime) {
+			t.Error("modified time should be fixed but", f.Name, f.Modified)
+		}
+	}
+
+	b := write(SaveOptions{Compression: CompressionStore})
+	r, _ = zip.NewReader(bytes.NewReader(b), int64(len(b)))
+	for _, f := range r.File {
+		if f.Method != zip.Store {
+			t.Error("part should not be compressed.", f.Name)
+		}
+	}
+	if best := write(SaveOptions{Compression: CompressionBest}); len(best) >= len(b) {
+		t.Error("compressed output should be smaller than stored output.", len(best), len(b))
+	}
+	workbook, err := OpenBytes(write(SaveOptions{Compression: CompressionBestSpeed}))
+	if err != nil {
+		t.Fatal("workbook should be opened.", err.Error())
+	}
+	workbook.Close()
+}